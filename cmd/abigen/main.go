@@ -0,0 +1,68 @@
+// Command abigen generates a typed Go binding for a Thinkey contract.
+//
+//	abigen -abi Token.abi -bin Token.bin -pkg token -type Token -out token.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"web3.go/web3/thk/bind"
+)
+
+func main() {
+	var (
+		abiFile  = flag.String("abi", "", "path to the contract's JSON ABI, - for stdin")
+		binFile  = flag.String("bin", "", "path to the contract's hex bytecode, to generate a deploy function")
+		pkg      = flag.String("pkg", "", "package name of the generated file")
+		typeName = flag.String("type", "", "name of the binding type, the package name capitalized by default")
+		out      = flag.String("out", "", "output file, stdout by default")
+	)
+	flag.Parse()
+
+	if *abiFile == "" || *pkg == "" {
+		fmt.Fprintln(os.Stderr, "abigen: -abi and -pkg are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *typeName == "" {
+		*typeName = strings.ToUpper((*pkg)[:1]) + (*pkg)[1:]
+	}
+
+	abiJSON, err := readFile(*abiFile)
+	if err != nil {
+		fatal(err)
+	}
+	var bytecode []byte
+	if *binFile != "" {
+		if bytecode, err = readFile(*binFile); err != nil {
+			fatal(err)
+		}
+	}
+	code, err := bind.Bind(*pkg, *typeName, string(abiJSON), string(bytecode))
+	if err != nil {
+		fatal(err)
+	}
+	if *out == "" {
+		fmt.Print(code)
+		return
+	}
+	if err := ioutil.WriteFile(*out, []byte(code), 0644); err != nil {
+		fatal(err)
+	}
+}
+
+func readFile(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "abigen:", err)
+	os.Exit(1)
+}
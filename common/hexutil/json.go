@@ -28,8 +28,11 @@ func (b Bytes) MarshalText() ([]byte, error) {
 	return result, nil
 }
 
-// UnmarshalJSON implements json.Unmarshaler.
+// UnmarshalJSON implements json.Unmarshaler. JSON null leaves b unchanged.
 func (b *Bytes) UnmarshalJSON(input []byte) error {
+	if string(input) == "null" {
+		return nil
+	}
 	if !isString(input) {
 		return errNonString(bytesT)
 	}
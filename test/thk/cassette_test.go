@@ -0,0 +1,38 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"web3.go/web3/providers"
+)
+
+// cassette returns the provider a test talks to. By default it replays
+// ../resources/cassettes/<test name>.json and fails the test if there is none.
+// With THK_RECORD=1 it talks to the node at address and rewrites the cassette.
+// No cassettes are checked in yet: they have to be recorded from a real node,
+// so until then these tests fail unless run with THK_RECORD=1.
+func cassette(t *testing.T, address string) providers.ProviderInterface {
+	path := filepath.Join("..", "resources", "cassettes", t.Name()+".json")
+	if os.Getenv("THK_RECORD") != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		recorder := providers.NewRecordingProvider(providers.NewHTTPProvider(address, 10, false), path)
+		t.Cleanup(func() {
+			if err := recorder.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+		return recorder
+	}
+	replay, err := providers.NewReplayProvider(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("no cassette at %s; record one with THK_RECORD=1 against %s", path, address)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return replay
+}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"strconv"
@@ -8,7 +9,6 @@ import (
 	"time"
 	"web3.go/common/cryp/crypto"
 	"web3.go/web3"
-	"web3.go/web3/providers"
 	"web3.go/web3/thk/util"
 )
 
@@ -25,7 +25,7 @@ func TestThkContract(t *testing.T) {
 		t.FailNow()
 	}
 
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("test.thinkey.xyz", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "test.thinkey.xyz"))
 
 	bytecode := unmarshalResponse.Bytecode
 	contract, err := connection.Thk.NewContract(unmarshalResponse.Abi)
@@ -44,14 +44,15 @@ func TestThkContract(t *testing.T) {
 		To: "", Value: "0", Input: "", Nonce: strconv.Itoa(int(nonce)),
 	}
 	privatekey, err := crypto.HexToECDSA(key)
-	hash, err := contract.Deploy(transaction, bytecode, privatekey, nil)
+	hash, err := contract.Deploy(transaction, bytecode, privatekey)
 	if err != nil {
 		t.Error(err)
 		t.FailNow()
 	}
 	t.Log(hash)
-	time.Sleep(time.Second * 5)
-	recepit, err := connection.Thk.GetTransactionByHash("2", hash)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	recepit, err := connection.Thk.WaitForTx(ctx, "2", hash)
 
 	if err != nil {
 		t.Error(err)
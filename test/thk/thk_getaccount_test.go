@@ -3,11 +3,10 @@ package test
 import (
 	"testing"
 	"web3.go/web3"
-	"web3.go/web3/providers"
 )
 
 func TestThkGetBalance(t *testing.T) {
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("thinkey.natapp1.cc", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "thinkey.natapp1.cc"))
 	connection.DefaultAddress = "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
 	bal, err := connection.Thk.GetBalance(connection.DefaultAddress, "2")
 	if err != nil {
@@ -18,7 +17,7 @@ func TestThkGetBalance(t *testing.T) {
 }
 
 func TestThkGetNonce(t *testing.T) {
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("thinkey.natapp1.cc", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "thinkey.natapp1.cc"))
 	connection.DefaultAddress = "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
 	nonce, err := connection.Thk.GetNonce(connection.DefaultAddress, "2")
 	if err != nil {
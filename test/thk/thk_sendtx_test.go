@@ -11,7 +11,6 @@ import (
 	"web3.go/common/hexutil"
 	"web3.go/encoding"
 	"web3.go/web3"
-	"web3.go/web3/providers"
 	"web3.go/web3/thk/util"
 )
 
@@ -178,7 +177,7 @@ func (a *Address) SetBytes(b []byte) {
 
 func TestThkCashCheck(t *testing.T) {
 	var err error
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("192.168.1.13:8089", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "192.168.1.13:8089"))
 	from := "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
 	to := "0x0000000000000000000000000000000000020000"
 
@@ -224,7 +223,7 @@ func TestThkCashCheck(t *testing.T) {
 }
 func TestThkSaveCashCheck(t *testing.T) {
 	var err error
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("192.168.1.13:8089", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "192.168.1.13:8089"))
 	from := "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
 	to := "0x0000000000000000000000000000000000030000"
 
@@ -269,7 +268,7 @@ func TestThkSaveCashCheck(t *testing.T) {
 
 func TestThkGetCommittee(t *testing.T) {
 	var err error
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("thinkey.natapp1.cc", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "thinkey.natapp1.cc"))
 
 	res, err := connection.Thk.GetCommittee("2", 100)
 	if err != nil {
@@ -281,7 +280,7 @@ func TestThkGetCommittee(t *testing.T) {
 
 func TestThkSendTx(t *testing.T) {
 	var err error
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("192.168.1.106:8093", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "192.168.1.106:8093"))
 	from := "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
 	to := "0x6ea0fefc17c877c7a4b0f139728ed39dc134a967"
 	nonce, err := connection.Thk.GetNonce(from, "2")
@@ -313,7 +312,7 @@ func TestThkSendTx(t *testing.T) {
 // Amount: value.(string),
 func TestThkRpcMakeVccProof(t *testing.T) {
 	var err error
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("192.168.1.13:8089", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "192.168.1.13:8089"))
 	from := "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
 	to := "0x0000000000000000000000000000000000020000"
 
@@ -338,7 +337,7 @@ func TestThkRpcMakeVccProof(t *testing.T) {
 	t.Log("input:", input)
 }
 func TestCompileContract(t *testing.T) {
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("192.168.1.13:8089", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "192.168.1.13:8089"))
 
 	contract := "pragma solidity >= 0.4.22;contract test {function multiply(uint a) public returns(uint d) {return a * 7;}}"
 	test, err := connection.Thk.CompileContract("2", contract)
@@ -351,7 +350,7 @@ func TestCompileContract(t *testing.T) {
 }
 func TestThkMakeCCCExistenceProof(t *testing.T) {
 	var err error
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("192.168.1.106:8093", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "192.168.1.106:8093"))
 	from := "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
 	to := "0x0000000000000000000000000000000000020000"
 
@@ -374,7 +373,7 @@ func TestThkMakeCCCExistenceProof(t *testing.T) {
 
 func TestThkCallTx(t *testing.T) {
 	var err error
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("192.168.1.13:8089", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "192.168.1.13:8089"))
 	from := "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
 	to := "0x6ea0fefc17c877c7a4b0f139728ed39dc134a967"
 	if err != nil {
@@ -395,7 +394,7 @@ func TestThkCallTx(t *testing.T) {
 
 func TestThkGetTransactionByHash(t *testing.T) {
 	var err error
-	var connection = web3.NewWeb3(providers.NewHTTPProvider("192.168.1.13:8093", 10, false))
+	var connection = web3.NewWeb3(cassette(t, "192.168.1.13:8093"))
 	hash := "0xcb53f1ec9c02053a46de488b63b219217826fd9c4cfb531567d61003664ef653"
 	res, err := connection.Thk.GetTransactionByHash("2", hash)
 	if err != nil {
@@ -5,6 +5,9 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
 )
 
 const (
@@ -86,4 +89,63 @@ func (u *Uint128) HexString() string {
 
 func (u *Uint128) String() string {
 	return fmt.Sprintf("0x%032x", u.HexString())
-}
\ No newline at end of file
+}
+
+// Big returns u as a big.Int.
+func (u *Uint128) Big() *big.Int {
+	n := new(big.Int).SetUint64(u.H)
+	n.Lsh(n, 64)
+	return n.Or(n, new(big.Int).SetUint64(u.L))
+}
+
+var maxUint64 = new(big.Int).SetUint64(^uint64(0))
+
+// SetBig sets u to n, which must be neither negative nor above 128 bits.
+func (u *Uint128) SetBig(n *big.Int) error {
+	if n.Sign() < 0 || n.BitLen() > 128 {
+		return fmt.Errorf("%v out of uint128 range", n)
+	}
+	u.H = new(big.Int).Rsh(n, 64).Uint64()
+	u.L = new(big.Int).And(n, maxUint64).Uint64()
+	return nil
+}
+
+// MarshalJSON writes u as a decimal JSON number.
+func (u Uint128) MarshalJSON() ([]byte, error) {
+	return []byte(u.Big().String()), nil
+}
+
+// exponentNotation matches the numbers nodes write in exponent notation, such
+// as 9.99999985e+26. Two exponent digits cover every uint128.
+var exponentNotation = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?[eE]\+?[0-9]{1,2}$`)
+
+// UnmarshalJSON decodes u without going through float64, which would round
+// anything above 2^53. It accepts a JSON number, in exponent notation too as
+// long as it is whole, or a decimal or 0x hex string.
+func (u *Uint128) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" {
+		return nil
+	}
+	var (
+		n  *big.Int
+		ok bool
+	)
+	switch {
+	case strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X"):
+		n, ok = new(big.Int).SetString(text[2:], 16)
+	case exponentNotation.MatchString(text):
+		var r *big.Rat
+		if r, ok = new(big.Rat).SetString(text); ok && r.IsInt() {
+			n = r.Num()
+		} else {
+			ok = false
+		}
+	default:
+		n, ok = new(big.Int).SetString(text, 10)
+	}
+	if !ok {
+		return fmt.Errorf("invalid uint128 %s", data)
+	}
+	return u.SetBig(n)
+}
@@ -1,15 +1,20 @@
 package dto
 
 import (
+	"bytes"
 	"errors"
+	"sort"
 	"strconv"
 	"strings"
+	"web3.go/common/hexutil"
 	"web3.go/web3/complex/types"
 	"web3.go/web3/constants"
 
 	"encoding/json"
 	"fmt"
 	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 type RequestResult struct {
@@ -26,6 +31,7 @@ type BalanceResult struct {
 	Balance     types.Uint128 `json:"balance"`
 	StorageRoot string        `json:"storageRoot"`
 	CodeHash    string        `json:"codeHash"`
+	ErrMsg      string        `json:"ErrMsg,omitempty"`
 }
 
 type SendTxResult struct {
@@ -62,14 +68,26 @@ type TransactionResult struct {
 
 type TxResult struct {
 	Transaction     TransactionResult
-	Root            string `json:"root"`
-	Status          int    `json:"status"`
-	Logs            string `json:"logs"`
-	TransactionHash string `json:"transactionHash"`
-	ContractAddress string `json:"contractAddress"`
-	Out             string `json:"out"`
-	BlockHeight     int    `json:"blockHeight"`
-	ErrMsg          string `json:"ErrMsg,omitempty"`
+	Root            hexutil.Bytes `json:"root"`
+	Status          int           `json:"status"`
+	Logs            []Log         `json:"logs"`
+	TransactionHash string        `json:"transactionHash"`
+	ContractAddress string        `json:"contractAddress"`
+	Out             string        `json:"out"`
+	BlockHeight     int           `json:"blockHeight"`
+	ErrMsg          string        `json:"ErrMsg,omitempty"`
+}
+
+// Log is an event emitted by a contract while executing a transaction.
+// Topics[0] is the event's signature hash unless the event is anonymous.
+type Log struct {
+	Address         common.Address `json:"address"`
+	Topics          []common.Hash  `json:"topics"`
+	Data            hexutil.Bytes  `json:"data"`
+	BlockHeight     int            `json:"blockNumber"`
+	TransactionHash string         `json:"transactionHash"`
+	TxIndex         int            `json:"transactionIndex"`
+	Index           int            `json:"index"`
 }
 
 type GetBlockResult struct {
@@ -86,13 +104,20 @@ type GetBlockResult struct {
 }
 
 type GetChainInfo struct {
-	ChainId      int    `json:"chainId"`
+	ChainId      int        `json:"chainId"`
+	DataNodeId   string     `json:"dataNodeId"`
+	DataNodeIp   string     `json:"dataNodeIp"`
+	DataNodePort int        `json:"dataNodePort"`
+	DataNodes    []DataNode `json:"datanodes"`
+	Mode         int        `json:"mode"`
+	Parent       int        `json:"parent"`
+	ErrMsg       string     `json:"ErrMsg,Omitempty"`
+}
+
+type DataNode struct {
 	DataNodeId   string `json:"dataNodeId"`
 	DataNodeIp   string `json:"dataNodeIp"`
 	DataNodePort int    `json:"dataNodePort"`
-	Mode         int    `json:"mode"`
-	Parent       int    `json:"parent"`
-	ErrMsg       string `json:"ErrMsg,Omitempty"`
 }
 
 /*
@@ -116,9 +141,38 @@ type GetTransactions struct {
 	Timestamp int64  `json:"timestamp"`
 }
 
+// BlockTxsResult is one page of the transactions of a block.
+type BlockTxsResult struct {
+	Elections      json.RawMessage `json:"elections"`
+	AccountChanges []BlockTx       `json:"accountchanges"`
+	ErrMsg         string          `json:"ErrMsg,omitempty"`
+}
+
+type BlockTx struct {
+	ChainId   int      `json:"chainid"`
+	Height    int      `json:"height"`
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Nonce     int      `json:"nonce"`
+	Value     *big.Int `json:"value"`
+	Input     string   `json:"input"`
+	Hash      string   `json:"hash"`
+	Timestamp int64    `json:"timestamp"`
+}
+
 type GetChainStats struct {
-	ChainId       int `json:"chainId"`
-	Currentheight int `json:"currentheight"`
+	ChainId           int      `json:"chainId"`
+	Currentheight     int      `json:"currentheight"`
+	Txcount           int      `json:"txcount"`
+	Tps               float64  `json:"tps"`
+	TpsLastEpoch      float64  `json:"tpsLastEpoch"`
+	Lives             int      `json:"lives"`
+	Accountcount      int      `json:"accountcount"`
+	Epochlength       int      `json:"epochlength"`
+	Epochduration     int      `json:"epochduration"`
+	Lastepochduration int      `json:"lastepochduration"`
+	Currentcomm       []string `json:"currentcomm"`
+	ErrMsg            string   `json:"ErrMsg,omitempty"`
 }
 
 type GetCommittee struct {
@@ -128,10 +182,51 @@ type GetCommittee struct {
 	ErrMsg        string   `json:"ErrMsg,Omitempty"`
 }
 
+// GetMultiStatsResult holds the stats of every chain, ordered by chain id.
 type GetMultiStatsResult struct {
+	Stats  []GetChainStats
 	ErrMsg string `json:"ErrMsg,Omitempty"`
 }
 
+// UnmarshalJSON accepts the stats as a list, as an object keyed by chain id,
+// or an object carrying only ErrMsg.
+func (result *GetMultiStatsResult) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	result.Stats, result.ErrMsg = nil, ""
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &result.Stats); err != nil {
+			return err
+		}
+	} else {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		for key, raw := range fields {
+			if strings.EqualFold(key, "errMsg") {
+				if err := json.Unmarshal(raw, &result.ErrMsg); err != nil {
+					return err
+				}
+				continue
+			}
+			chainId, err := strconv.Atoi(key)
+			if err != nil {
+				return fmt.Errorf("unexpected field %q in multi-chain stats", key)
+			}
+			stats := GetChainStats{}
+			if err := json.Unmarshal(raw, &stats); err != nil {
+				return err
+			}
+			stats.ChainId = chainId
+			result.Stats = append(result.Stats, stats)
+		}
+	}
+	sort.Slice(result.Stats, func(i, j int) bool {
+		return result.Stats[i].ChainId < result.Stats[j].ChainId
+	})
+	return nil
+}
+
 type Error struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
@@ -215,7 +310,7 @@ func (pointer *RequestResult) ToBigInt() (*big.Int, error) {
 func (pointer *RequestResult) ToComplexIntResponse() (types.ComplexIntResponse, error) {
 
 	if err := pointer.checkResponse(); err != nil {
-		return types.ComplexIntResponse(0), err
+		return types.ComplexIntResponse(""), err
 	}
 
 	result := (pointer).Result.(interface{})
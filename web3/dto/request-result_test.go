@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestBalanceResultUnmarshal(t *testing.T) {
+	for _, tt := range []struct {
+		balance string
+		want    string
+	}{
+		{`1267650600228229401496703205376`, "1267650600228229401496703205376"},
+		{`9.99999985e+26`, "999999985000000000000000000"},
+		{`"123456789012345678901234567890"`, "123456789012345678901234567890"},
+		{`"0xde0b6b3a7640000"`, "1000000000000000000"},
+		{`0`, "0"},
+		{`340282366920938463463374607431768211455`, "340282366920938463463374607431768211455"},
+	} {
+		var res BalanceResult
+		body := `{"address":"0x2c7536e3605d9c16a7a3d7b1898e529396a65c23","nonce":43,"balance":` + tt.balance + `,"storageRoot":null,"codeHash":null}`
+		if err := json.Unmarshal([]byte(body), &res); err != nil {
+			t.Errorf("%s: %v", tt.balance, err)
+			continue
+		}
+		if res.Balance.Big().String() != tt.want || res.Nonce != 43 || res.Address == "" {
+			t.Errorf("%s: got %+v, want balance %s", tt.balance, res, tt.want)
+		}
+	}
+
+	var res BalanceResult
+	for _, balance := range []string{`1.5`, `-1`, `"-0x1"`, `1e999999999`, `1e39`, `340282366920938463463374607431768211456`} {
+		if err := json.Unmarshal([]byte(`{"balance":`+balance+`}`), &res); err == nil {
+			t.Errorf("balance %s accepted", balance)
+		}
+	}
+	res = BalanceResult{}
+	if err := json.Unmarshal([]byte(`{"ErrMsg":"account not found"}`), &res); err != nil || res.ErrMsg == "" || res.Balance.Big().Sign() != 0 {
+		t.Errorf("error response decoded as %+v, %v", res, err)
+	}
+
+	// The balance round-trips as a decimal number.
+	res.Balance.SetBig(big.NewInt(1e18))
+	body, err := json.Marshal(res.Balance)
+	if err != nil || string(body) != "1000000000000000000" {
+		t.Errorf("balance marshalled as %s, %v", body, err)
+	}
+}
+
+func TestGetMultiStatsResultUnmarshal(t *testing.T) {
+	for _, body := range []string{
+		`[{"chainId":2,"currentheight":7},{"chainId":1,"currentheight":9}]`,
+		`{"2":{"currentheight":7},"1":{"currentheight":9}}`,
+	} {
+		var res GetMultiStatsResult
+		if err := json.Unmarshal([]byte(body), &res); err != nil {
+			t.Errorf("%s: %v", body, err)
+			continue
+		}
+		if len(res.Stats) != 2 || res.Stats[0].ChainId != 1 || res.Stats[0].Currentheight != 9 || res.Stats[1].Currentheight != 7 {
+			t.Errorf("%s: got %+v", body, res.Stats)
+		}
+	}
+
+	var stats GetMultiStatsResult
+	if err := json.Unmarshal([]byte(`[{"chainId":1,"tps":12.25,"tpsLastEpoch":3.75},{"chainId":2,"tps":0.5}]`), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Stats) != 2 || stats.Stats[0].Tps != 12.25 || stats.Stats[0].TpsLastEpoch != 3.75 || stats.Stats[1].Tps != 0.5 {
+		t.Errorf("fractional tps decoded as %+v", stats.Stats)
+	}
+
+	var res GetMultiStatsResult
+	if err := json.Unmarshal([]byte(`{"errMsg":"not ready"}`), &res); err != nil || res.ErrMsg != "not ready" || len(res.Stats) != 0 {
+		t.Errorf("error response decoded as %+v, %v", res, err)
+	}
+}
@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchParallelism bounds the requests in flight when a batch has to be
+// sent as individual requests.
+const DefaultBatchParallelism = 8
+
+// BatchElem is one request of a batch. After the batch is sent Result holds the
+// decoded response and Error the failure of this request, if any.
+type BatchElem struct {
+	Method string
+	Params interface{}
+	Result interface{}
+	Error  error
+}
+
+// BatchProviderInterface is implemented by providers that can send several
+// requests in one round trip. parallelism bounds the individual requests used
+// when the node turns out not to support batching.
+type BatchProviderInterface interface {
+	ProviderInterface
+	SendBatchContext(ctx context.Context, batch []BatchElem, parallelism int) error
+}
+
+// SendBatchContext sends batch through provider, in one request if the provider
+// supports it and otherwise as concurrent individual requests. The returned
+// error is only set when the batch as a whole failed, and is then also the
+// Error of each BatchElem left without a result; per-request failures are
+// reported in each BatchElem.
+func SendBatchContext(ctx context.Context, provider ProviderInterface, batch []BatchElem, parallelism int) error {
+	if p, ok := provider.(BatchProviderInterface); ok {
+		return p.SendBatchContext(ctx, batch, parallelism)
+	}
+	return sendPipelined(ctx, provider, batch, parallelism)
+}
+
+func sendPipelined(ctx context.Context, provider ProviderInterface, batch []BatchElem, parallelism int) error {
+	if parallelism <= 0 {
+		parallelism = DefaultBatchParallelism
+	}
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, parallelism)
+	)
+	for i := range batch {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for ; i < len(batch); i++ {
+				batch[i].Error = ctx.Err()
+			}
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(elem *BatchElem) {
+			defer func() { <-sem; wg.Done() }()
+			elem.Error = SendRequestContext(ctx, provider, elem.Result, elem.Method, elem.Params)
+		}(&batch[i])
+	}
+	wg.Wait()
+	return nil
+}
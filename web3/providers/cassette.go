@@ -0,0 +1,207 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+// Interaction is one recorded request and the node's raw response to it. Error
+// is set instead of Response when the request failed before the node answered.
+type Interaction struct {
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Cassette is the file format shared by RecordingProvider and ReplayProvider.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads a cassette written by RecordingProvider.
+func LoadCassette(path string) (*Cassette, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cassette := new(Cassette)
+	if err := json.Unmarshal(content, cassette); err != nil {
+		return nil, fmt.Errorf("cassette %s: %v", path, err)
+	}
+	return cassette, nil
+}
+
+// Save writes the cassette to path, indented so it diffs well when checked in.
+func (cassette *Cassette) Save(path string) error {
+	content, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// RecordingProvider passes requests through to a live provider and records
+// each of them with its response. The cassette is written on Close.
+type RecordingProvider struct {
+	provider ProviderInterface
+	path     string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+func NewRecordingProvider(provider ProviderInterface, path string) *RecordingProvider {
+	return &RecordingProvider{provider: provider, path: path}
+}
+
+func (provider *RecordingProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	return provider.SendRequestContext(context.Background(), v, method, params)
+}
+
+func (provider *RecordingProvider) SendRequestContext(ctx context.Context, v interface{}, method string, params interface{}) error {
+	key, err := canonicalParams(params)
+	if err != nil {
+		return &ProviderError{Method: method, Err: err}
+	}
+	var raw json.RawMessage
+	err = SendRequestContext(ctx, provider.provider, &raw, method, params)
+	if err != nil && ctx.Err() != nil {
+		// Not something the node did; leave it out of the cassette.
+		return err
+	}
+
+	interaction := Interaction{Method: method, Params: key}
+	if err != nil {
+		interaction.Error = err.Error()
+	} else {
+		interaction.Response = raw
+	}
+	provider.mu.Lock()
+	provider.cassette.Interactions = append(provider.cassette.Interactions, interaction)
+	provider.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return decodeRecorded(method, raw, v)
+}
+
+// Save writes what has been recorded so far.
+func (provider *RecordingProvider) Save() error {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	return provider.cassette.Save(provider.path)
+}
+
+// Close saves the cassette and closes the live provider.
+func (provider *RecordingProvider) Close() error {
+	err := provider.Save()
+	if cerr := provider.provider.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ErrUnmatchedRequest is the cause of the error ReplayProvider returns for a
+// request that is not in its cassette.
+var ErrUnmatchedRequest = errors.New("request not found in cassette")
+
+// ReplayProvider answers requests from a cassette without a node. A request
+// matches an interaction with the same method and params, regardless of field
+// order. Repeated requests get the recorded responses in order, the last one
+// being served again once they run out, so polling loops keep working.
+type ReplayProvider struct {
+	path string
+
+	mu        sync.Mutex
+	responses map[string][]Interaction
+}
+
+func NewReplayProvider(path string) (*ReplayProvider, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	provider := &ReplayProvider{path: path, responses: make(map[string][]Interaction)}
+	for _, interaction := range cassette.Interactions {
+		key, err := canonicalParams(interaction.Params)
+		if err != nil {
+			return nil, fmt.Errorf("cassette %s: %s params: %v", path, interaction.Method, err)
+		}
+		k := replayKey(interaction.Method, key)
+		provider.responses[k] = append(provider.responses[k], interaction)
+	}
+	return provider, nil
+}
+
+func (provider *ReplayProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	return provider.SendRequestContext(context.Background(), v, method, params)
+}
+
+func (provider *ReplayProvider) SendRequestContext(ctx context.Context, v interface{}, method string, params interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	key, err := canonicalParams(params)
+	if err != nil {
+		return &ProviderError{Method: method, Err: err}
+	}
+
+	k := replayKey(method, key)
+	provider.mu.Lock()
+	queue := provider.responses[k]
+	if len(queue) == 0 {
+		provider.mu.Unlock()
+		return &ProviderError{Method: method, Err: fmt.Errorf("%w (%s): %s", ErrUnmatchedRequest, provider.path, key)}
+	}
+	interaction := queue[0]
+	if len(queue) > 1 {
+		provider.responses[k] = queue[1:]
+	}
+	provider.mu.Unlock()
+
+	if interaction.Error != "" {
+		return &ProviderError{Method: method, Err: errors.New(interaction.Error)}
+	}
+	return decodeRecorded(method, interaction.Response, v)
+}
+
+func (provider *ReplayProvider) Close() error {
+	return nil
+}
+
+// canonicalParams encodes params with object keys sorted, so that the same
+// request matches whatever type it was built from.
+func canonicalParams(params interface{}) (json.RawMessage, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+func replayKey(method string, params json.RawMessage) string {
+	return method + " " + string(params)
+}
+
+func decodeRecorded(method string, raw json.RawMessage, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &ProviderError{Method: method, Body: raw, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package providers
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	var served int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		if served == 1 {
+			w.Write([]byte(`{"nonce":1}`))
+			return
+		}
+		w.Write([]byte(`{"nonce":2}`))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.json")
+
+	recorder := NewRecordingProvider(NewHTTPProvider(strings.TrimPrefix(server.URL, "http://"), 10, false), path)
+	for i := 0; i < 2; i++ {
+		res := make(map[string]int)
+		if err := recorder.SendRequest(&res, "GetAccount", map[string]string{"chainId": "2", "address": "0x2c"}); err != nil {
+			t.Fatal(err)
+		}
+		if res["nonce"] != i+1 {
+			t.Errorf("recording: got nonce %d, want %d", res["nonce"], i+1)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	replay, err := NewReplayProvider(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Field order and params type don't matter; the last response repeats.
+	params := struct {
+		Address string `json:"address"`
+		ChainId string `json:"chainId"`
+	}{"0x2c", "2"}
+	for _, want := range []int{1, 2, 2} {
+		res := make(map[string]int)
+		if err := replay.SendRequest(&res, "GetAccount", params); err != nil {
+			t.Fatal(err)
+		}
+		if res["nonce"] != want {
+			t.Errorf("replay: got nonce %d, want %d", res["nonce"], want)
+		}
+	}
+
+	err = replay.SendRequest(nil, "GetAccount", map[string]string{"chainId": "3", "address": "0x2c"})
+	if !errors.Is(err, ErrUnmatchedRequest) || !strings.Contains(err.Error(), `"chainId":"3"`) {
+		t.Errorf("got %v, want ErrUnmatchedRequest naming the params", err)
+	}
+}
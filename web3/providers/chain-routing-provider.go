@@ -0,0 +1,340 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web3.go/web3/dto"
+)
+
+const (
+	DefaultChainRefreshInterval = time.Minute
+	DefaultChainDrainDelay      = 30 * time.Second
+)
+
+type ChainRoutingOptions struct {
+	// Dial creates the provider for a data node given as "ip:port". The
+	// default is a plain HTTPProvider with a 10 second timeout.
+	Dial func(address string) ProviderInterface
+	// RefreshInterval is how often the chain map is reloaded from the
+	// bootstrap node. Zero means DefaultChainRefreshInterval, a negative value
+	// disables refreshing.
+	RefreshInterval time.Duration
+	// DrainDelay is how long a data node dropped from the chain map stays
+	// open, so that requests already routed to it can finish. Zero means
+	// DefaultChainDrainDelay.
+	DrainDelay time.Duration
+}
+
+// ChainRoutingProvider sends each request to the data node serving the chain
+// named by the chainId field of its params. A chain served by several data
+// nodes is routed through a FailoverProvider over all of them. The chain to
+// node map is read with GetChainInfo from a bootstrap node, which also serves
+// requests that carry no chainId or name a chain it did not report.
+type ChainRoutingProvider struct {
+	bootstrap ProviderInterface
+	options   ChainRoutingOptions
+
+	mu      sync.RWMutex
+	routes  map[string]ProviderInterface // chain id -> node or group
+	nodes   map[string]ProviderInterface // node address -> node
+	groups  map[string]*chainGroup       // chain id -> its data nodes
+	retired map[string]*retiredNode      // node address -> dropped node
+
+	quit      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewChainRoutingProvider loads the chain map from bootstrap and keeps it
+// fresh in the background until Close.
+func NewChainRoutingProvider(bootstrap ProviderInterface, options ChainRoutingOptions) (*ChainRoutingProvider, error) {
+	provider := new(ChainRoutingProvider)
+	provider.bootstrap = bootstrap
+	provider.options = options
+	if provider.options.Dial == nil {
+		provider.options.Dial = func(address string) ProviderInterface {
+			return NewHTTPProvider(address, 10, false)
+		}
+	}
+	if provider.options.RefreshInterval == 0 {
+		provider.options.RefreshInterval = DefaultChainRefreshInterval
+	}
+	if provider.options.DrainDelay == 0 {
+		provider.options.DrainDelay = DefaultChainDrainDelay
+	}
+	provider.routes = make(map[string]ProviderInterface)
+	provider.nodes = make(map[string]ProviderInterface)
+	provider.groups = make(map[string]*chainGroup)
+	provider.retired = make(map[string]*retiredNode)
+	provider.quit = make(chan struct{})
+
+	if err := provider.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	if provider.options.RefreshInterval > 0 {
+		provider.wg.Add(1)
+		go provider.refreshLoop()
+	}
+	return provider, nil
+}
+
+func (provider *ChainRoutingProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	return provider.SendRequestContext(context.Background(), v, method, params)
+}
+
+func (provider *ChainRoutingProvider) SendRequestContext(ctx context.Context, v interface{}, method string, params interface{}) error {
+	return SendRequestContext(ctx, provider.route(params), v, method, params)
+}
+
+// Route returns the node requests for chainId are sent to.
+func (provider *ChainRoutingProvider) Route(chainId string) ProviderInterface {
+	provider.mu.RLock()
+	defer provider.mu.RUnlock()
+
+	if node, ok := provider.routes[chainId]; ok {
+		return node
+	}
+	return provider.bootstrap
+}
+
+func (provider *ChainRoutingProvider) route(params interface{}) ProviderInterface {
+	if chainId, ok := chainIdOf(params); ok {
+		return provider.Route(chainId)
+	}
+	return provider.bootstrap
+}
+
+// Refresh reloads the chain map from the bootstrap node. Nodes that no longer
+// serve any chain are closed after DrainDelay, since requests Route handed
+// them to may still be running; a node that comes back before is reused.
+func (provider *ChainRoutingProvider) Refresh(ctx context.Context) error {
+	var raw json.RawMessage
+	if err := SendRequestContext(ctx, provider.bootstrap, &raw, "GetChainInfo", map[string][]int{"chainId": {}}); err != nil {
+		return err
+	}
+	infos, err := decodeChainInfos(raw)
+	if err != nil {
+		return err
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	routes := make(map[string]ProviderInterface)
+	nodes := make(map[string]ProviderInterface)
+	groups := make(map[string]*chainGroup)
+	for _, info := range infos {
+		addresses := chainNodeAddresses(info)
+		if len(addresses) == 0 {
+			continue
+		}
+		chainNodes := make([]ProviderInterface, len(addresses))
+		for i, address := range addresses {
+			node, ok := nodes[address]
+			if !ok {
+				node = provider.node(address)
+				nodes[address] = node
+			}
+			chainNodes[i] = node
+		}
+		chainId := strconv.Itoa(info.ChainId)
+		if len(chainNodes) == 1 {
+			routes[chainId] = chainNodes[0]
+			continue
+		}
+		key := strings.Join(addresses, ",")
+		group, ok := provider.groups[chainId]
+		if !ok || group.key != key {
+			group = newChainGroup(chainId, key, chainNodes)
+		}
+		groups[chainId] = group
+		routes[chainId] = group.provider
+	}
+	for address, node := range provider.nodes {
+		if _, ok := nodes[address]; !ok {
+			provider.retire(address, node)
+		}
+	}
+	for chainId, group := range provider.groups {
+		if groups[chainId] != group {
+			// Closing a group only stops its health checks; requests
+			// already routed to it keep using its nodes.
+			group.provider.Close()
+		}
+	}
+	provider.routes = routes
+	provider.nodes = nodes
+	provider.groups = groups
+	return nil
+}
+
+// chainGroup routes a chain over the data nodes serving it. key lists their
+// addresses, so that a group is rebuilt only when they change.
+type chainGroup struct {
+	key      string
+	provider *FailoverProvider
+}
+
+func newChainGroup(chainId, key string, nodes []ProviderInterface) *chainGroup {
+	shared := make([]ProviderInterface, len(nodes))
+	for i, node := range nodes {
+		shared[i] = sharedNode{node}
+	}
+	return &chainGroup{
+		key:      key,
+		provider: NewFailoverProvider(shared, FailoverOptions{HealthCheckChainId: chainId}),
+	}
+}
+
+// sharedNode lends a data node to a FailoverProvider without letting it close
+// the node, which other chains may use too.
+type sharedNode struct {
+	ProviderInterface
+}
+
+func (node sharedNode) SendRequestContext(ctx context.Context, v interface{}, method string, params interface{}) error {
+	return SendRequestContext(ctx, node.ProviderInterface, v, method, params)
+}
+
+func (node sharedNode) Close() error { return nil }
+
+// retiredNode is a node dropped from the chain map, waiting to be closed.
+type retiredNode struct {
+	node  ProviderInterface
+	timer *time.Timer
+}
+
+// node returns the provider for the data node at address, reusing the current
+// or a retired one if there is one. Called with mu held.
+func (provider *ChainRoutingProvider) node(address string) ProviderInterface {
+	if node, ok := provider.nodes[address]; ok {
+		return node
+	}
+	if retired, ok := provider.retired[address]; ok {
+		retired.timer.Stop()
+		delete(provider.retired, address)
+		return retired.node
+	}
+	return provider.options.Dial(address)
+}
+
+// retire closes node after DrainDelay unless node picks it up again first.
+// Called with mu held.
+func (provider *ChainRoutingProvider) retire(address string, node ProviderInterface) {
+	retired := &retiredNode{node: node}
+	provider.retired[address] = retired
+	retired.timer = time.AfterFunc(provider.options.DrainDelay, func() {
+		provider.mu.Lock()
+		if provider.retired[address] != retired {
+			provider.mu.Unlock()
+			return
+		}
+		delete(provider.retired, address)
+		provider.mu.Unlock()
+		node.Close()
+	})
+}
+
+// Close stops refreshing and closes the data nodes, including the ones still
+// draining, and the bootstrap node.
+// Only the first call does anything.
+func (provider *ChainRoutingProvider) Close() error {
+	var err error
+	provider.closeOnce.Do(func() {
+		close(provider.quit)
+		provider.wg.Wait()
+
+		provider.mu.Lock()
+		var nodes []ProviderInterface
+		for _, group := range provider.groups {
+			nodes = append(nodes, group.provider)
+		}
+		for _, node := range provider.nodes {
+			nodes = append(nodes, node)
+		}
+		for _, retired := range provider.retired {
+			retired.timer.Stop()
+			nodes = append(nodes, retired.node)
+		}
+		provider.nodes = make(map[string]ProviderInterface)
+		provider.routes = make(map[string]ProviderInterface)
+		provider.groups = make(map[string]*chainGroup)
+		provider.retired = make(map[string]*retiredNode)
+		provider.mu.Unlock()
+
+		for _, node := range nodes {
+			node.Close()
+		}
+		err = provider.bootstrap.Close()
+	})
+	return err
+}
+
+func (provider *ChainRoutingProvider) refreshLoop() {
+	defer provider.wg.Done()
+
+	ticker := time.NewTicker(provider.options.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), provider.options.RefreshInterval)
+			// A failed refresh keeps the previous map.
+			provider.Refresh(ctx)
+			cancel()
+		case <-provider.quit:
+			return
+		}
+	}
+}
+
+// decodeChainInfos accepts the list the node returns for GetChainInfo as well
+// as a single object, which is how it reports an ErrMsg.
+func decodeChainInfos(raw json.RawMessage) ([]dto.GetChainInfo, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var infos []dto.GetChainInfo
+		if err := json.Unmarshal(raw, &infos); err != nil {
+			return nil, &ProviderError{Method: "GetChainInfo", Body: raw, Err: err}
+		}
+		return infos, nil
+	}
+	var info dto.GetChainInfo
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return nil, &ProviderError{Method: "GetChainInfo", Body: raw, Err: err}
+	}
+	if info.ErrMsg != "" {
+		return nil, &NodeError{Method: "GetChainInfo", Message: info.ErrMsg}
+	}
+	if len(chainNodeAddresses(info)) == 0 {
+		return nil, &ProviderError{Method: "GetChainInfo", Body: raw, Err: errors.New("no data nodes reported")}
+	}
+	return []dto.GetChainInfo{info}, nil
+}
+
+// chainNodeAddresses returns the addresses of the data nodes serving a chain,
+// from its DataNodes or, from older nodes, its single DataNodeIp and port.
+func chainNodeAddresses(info dto.GetChainInfo) []string {
+	dataNodes := info.DataNodes
+	if len(dataNodes) == 0 {
+		dataNodes = []dto.DataNode{{DataNodeIp: info.DataNodeIp, DataNodePort: info.DataNodePort}}
+	}
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, node := range dataNodes {
+		address := fmt.Sprintf("%s:%d", node.DataNodeIp, node.DataNodePort)
+		if node.DataNodeIp != "" && node.DataNodePort != 0 && !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
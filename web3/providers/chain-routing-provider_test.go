@@ -0,0 +1,203 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"web3.go/web3/dto"
+)
+
+func TestChainRoutingProvider(t *testing.T) {
+	named := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]string{"node": name})
+		}))
+	}
+	one, two := named("one"), named("two")
+	defer one.Close()
+	defer two.Close()
+
+	dataNode := func(server *httptest.Server) dto.DataNode {
+		host, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+		p, _ := strconv.Atoi(port)
+		return dto.DataNode{DataNodeIp: host, DataNodePort: p}
+	}
+	bootstrap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Method string }
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "GetChainInfo" {
+			json.NewEncoder(w).Encode(map[string]string{"node": "bootstrap"})
+			return
+		}
+		json.NewEncoder(w).Encode([]dto.GetChainInfo{
+			{ChainId: 1, DataNodes: []dto.DataNode{dataNode(one)}},
+			{ChainId: 2, DataNodes: []dto.DataNode{dataNode(two)}},
+			{ChainId: 3, DataNodeIp: dataNode(one).DataNodeIp, DataNodePort: dataNode(one).DataNodePort},
+		})
+	}))
+	defer bootstrap.Close()
+
+	provider, err := NewChainRoutingProvider(NewHTTPProvider(strings.TrimPrefix(bootstrap.URL, "http://"), 10, false), ChainRoutingOptions{RefreshInterval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Close()
+
+	for _, tt := range []struct {
+		params interface{}
+		want   string
+	}{
+		{map[string]string{"chainId": "1"}, "one"},
+		{map[string]string{"chainId": "2"}, "two"},
+		{map[string]int{"chainId": 3}, "one"},
+		{map[string]string{"chainId": "7"}, "bootstrap"},
+		{nil, "bootstrap"},
+	} {
+		res := make(map[string]string)
+		if err := provider.SendRequest(&res, "GetAccount", tt.params); err != nil {
+			t.Fatal(err)
+		}
+		if res["node"] != tt.want {
+			t.Errorf("params %v went to %q, want %q", tt.params, res["node"], tt.want)
+		}
+	}
+	if provider.Route("1") != provider.Route("3") {
+		t.Error("chains served by the same data node should share a provider")
+	}
+
+	provider.Close()
+	if err := provider.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestChainRoutingProviderSeveralDataNodes(t *testing.T) {
+	var hits [2]int32
+	server := func(i int, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits[i], 1)
+			w.WriteHeader(status)
+			w.Write([]byte(`{"nonce":1}`))
+		}))
+	}
+	dead, alive := server(0, http.StatusBadGateway), server(1, http.StatusOK)
+	defer dead.Close()
+	defer alive.Close()
+	dataNode := func(server *httptest.Server) dto.DataNode {
+		host, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+		p, _ := strconv.Atoi(port)
+		return dto.DataNode{DataNodeIp: host, DataNodePort: p}
+	}
+	bootstrap := &funcProvider{fn: func(string) error { return nil }}
+	infos := []dto.GetChainInfo{{ChainId: 2, DataNodes: []dto.DataNode{dataNode(dead), dataNode(alive)}}}
+	provider, err := NewChainRoutingProvider(chainInfosProvider{bootstrap, func() []dto.GetChainInfo { return infos }}, ChainRoutingOptions{RefreshInterval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Close()
+
+	for i := 0; i < 4; i++ {
+		res := make(map[string]interface{})
+		if err := provider.SendRequest(&res, "GetAccount", map[string]string{"chainId": "2"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d, a := atomic.LoadInt32(&hits[0]), atomic.LoadInt32(&hits[1]); d != 1 || a != 4 {
+		t.Errorf("dead/alive data node hits = %d/%d, want 1/4", d, a)
+	}
+	if atomic.LoadInt32(&bootstrap.calls) != 0 {
+		t.Error("a request for chain 2 went to the bootstrap node")
+	}
+	group := provider.Route("2")
+	if err := provider.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if provider.Route("2") != group {
+		t.Error("unchanged data nodes should keep their group")
+	}
+}
+
+// chainInfosProvider answers GetChainInfo with what infos returns and passes
+// every other request to its provider.
+type chainInfosProvider struct {
+	ProviderInterface
+	infos func() []dto.GetChainInfo
+}
+
+func (p chainInfosProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	if method != "GetChainInfo" {
+		return p.ProviderInterface.SendRequest(v, method, params)
+	}
+	body, _ := json.Marshal(p.infos())
+	return json.Unmarshal(body, v)
+}
+
+// closeCounting counts how often a node is closed.
+type closeCounting struct {
+	ProviderInterface
+	closed int32
+}
+
+func (p *closeCounting) Close() error {
+	atomic.AddInt32(&p.closed, 1)
+	return p.ProviderInterface.Close()
+}
+
+func TestChainRoutingProviderDrainsDroppedNodes(t *testing.T) {
+	var serving atomic.Value
+	serving.Store("10.0.0.1")
+	bootstrap := &funcProvider{}
+	// Chains 1 and 2 are served by the data node whose ip serving holds.
+	infos := func() []dto.GetChainInfo {
+		ip := serving.Load().(string)
+		return []dto.GetChainInfo{
+			{ChainId: 1, DataNodeIp: ip, DataNodePort: 8089},
+			{ChainId: 2, DataNodeIp: ip, DataNodePort: 8089},
+		}
+	}
+	provider, err := NewChainRoutingProvider(chainInfosProvider{bootstrap, infos}, ChainRoutingOptions{
+		Dial:            func(address string) ProviderInterface { return &closeCounting{ProviderInterface: &funcProvider{}} },
+		RefreshInterval: -1,
+		DrainDelay:      50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Close()
+	ctx := context.Background()
+
+	first := provider.Route("1").(*closeCounting)
+	serving.Store("10.0.0.2")
+	if err := provider.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if provider.Route("1") == first || atomic.LoadInt32(&first.closed) != 0 {
+		t.Fatal("the dropped node should be replaced but stay open while draining")
+	}
+	// A node that comes back while draining is reused.
+	serving.Store("10.0.0.1")
+	if err := provider.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if provider.Route("1") != first {
+		t.Error("a node back before its drain delay should be reused")
+	}
+	second := provider.Route("2").(*closeCounting)
+
+	serving.Store("10.0.0.3")
+	if err := provider.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if a, b := atomic.LoadInt32(&first.closed), atomic.LoadInt32(&second.closed); a != 1 || b != 1 {
+		t.Errorf("dropped nodes closed %d and %d times, want once after the drain delay", a, b)
+	}
+}
@@ -0,0 +1,43 @@
+package providers
+
+import "fmt"
+
+// ProviderError is returned when a request could not be carried out: the body
+// could not be encoded, the transport failed, the node answered with a non-200
+// status or with a body that could not be decoded.
+type ProviderError struct {
+	Method     string
+	StatusCode int    // HTTP status, 0 if no response was received
+	Body       []byte // raw response body, if any
+	Err        error  // underlying cause, if any
+}
+
+func (e *ProviderError) Error() string {
+	switch {
+	case e.StatusCode != 0 && e.StatusCode != 200:
+		if len(e.Body) > 0 {
+			return fmt.Sprintf("%s: http status %d: %s", e.Method, e.StatusCode, e.Body)
+		}
+		return fmt.Sprintf("%s: http status %d", e.Method, e.StatusCode)
+	case e.Err != nil:
+		return fmt.Sprintf("%s: %v", e.Method, e.Err)
+	default:
+		return fmt.Sprintf("%s: request failed", e.Method)
+	}
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// NodeError is an error message (ErrMsg) returned by the node for a request it
+// received and rejected.
+type NodeError struct {
+	Method  string
+	Message string
+}
+
+// Error returns the node's message unchanged.
+func (e *NodeError) Error() string {
+	return e.Message
+}
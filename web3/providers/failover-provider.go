@@ -0,0 +1,341 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type FailoverPolicy int
+
+const (
+	// RoundRobin spreads requests evenly over the healthy nodes.
+	RoundRobin FailoverPolicy = iota
+	// LeastLatency prefers the healthy node that has been answering fastest.
+	LeastLatency
+	// PerChain sends a request to the nodes listed for its chainId in
+	// FailoverOptions.ChainNodes, round robin among them.
+	PerChain
+)
+
+const (
+	DefaultHealthCheckInterval = 10 * time.Second
+
+	latencyWeight = 0.2
+)
+
+// nonIdempotentMethods are never resent automatically: the node may already
+// have applied the first attempt, and resending a signed transaction would at
+// best fail on its nonce and at worst be applied twice.
+var nonIdempotentMethods = map[string]bool{
+	"SendTx": true,
+}
+
+// IsIdempotent reports whether method can be resent safely after a failure.
+func IsIdempotent(method string) bool {
+	return !nonIdempotentMethods[method]
+}
+
+type FailoverOptions struct {
+	Policy FailoverPolicy
+
+	// ChainNodes maps a chain id to the indexes of the nodes serving it. Only
+	// used by the PerChain policy; chains without an entry use every node.
+	ChainNodes map[string][]int
+
+	// HealthCheck probes a node. The default sends the same Ping request as
+	// Thk.Ping for HealthCheckChainId, and fails unless the node answers with
+	// the account nonce Ping reports.
+	HealthCheck func(ctx context.Context, node ProviderInterface) error
+	// HealthCheckChainId is the chain the default health check pings, which
+	// every node must serve. Empty means chain "0".
+	HealthCheckChainId string
+	// HealthCheckInterval is how often the nodes are probed. Zero means
+	// DefaultHealthCheckInterval, a negative value disables probing.
+	HealthCheckInterval time.Duration
+
+	// MaxAttempts bounds how many nodes an idempotent request is tried on.
+	// Zero means every node.
+	MaxAttempts int
+}
+
+// FailoverProvider spreads requests over several nodes. A node that fails at
+// the transport level is ejected until a health check passes again; reads that
+// fail this way are retried on another node, non-idempotent requests such as
+// SendTx never are.
+type FailoverProvider struct {
+	nodes   []*failoverNode
+	options FailoverOptions
+	next    uint32
+
+	quit      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type failoverNode struct {
+	provider ProviderInterface
+
+	mu      sync.Mutex
+	healthy bool
+	latency time.Duration
+}
+
+func NewFailoverProvider(nodes []ProviderInterface, options FailoverOptions) *FailoverProvider {
+	provider := new(FailoverProvider)
+	provider.options = options
+	if provider.options.HealthCheck == nil {
+		chainId := provider.options.HealthCheckChainId
+		if chainId == "" {
+			chainId = "0"
+		}
+		provider.options.HealthCheck = pingHealthCheck(chainId)
+	}
+	if provider.options.HealthCheckInterval == 0 {
+		provider.options.HealthCheckInterval = DefaultHealthCheckInterval
+	}
+	for _, node := range nodes {
+		provider.nodes = append(provider.nodes, &failoverNode{provider: node, healthy: true})
+	}
+	provider.quit = make(chan struct{})
+
+	if provider.options.HealthCheckInterval > 0 {
+		provider.wg.Add(1)
+		go provider.healthLoop()
+	}
+	return provider
+}
+
+func (provider *FailoverProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	return provider.SendRequestContext(context.Background(), v, method, params)
+}
+
+func (provider *FailoverProvider) SendRequestContext(ctx context.Context, v interface{}, method string, params interface{}) error {
+	candidates := provider.candidates(params)
+	if len(candidates) == 0 {
+		return &ProviderError{Method: method, Err: errNoNodes}
+	}
+	attempts := 1
+	if IsIdempotent(method) {
+		attempts = len(candidates)
+		if max := provider.options.MaxAttempts; max > 0 && max < attempts {
+			attempts = max
+		}
+	}
+
+	var err error
+	for _, node := range candidates[:attempts] {
+		start := time.Now()
+		err = SendRequestContext(ctx, node.provider, v, method, params)
+		if !IsTransportError(err) {
+			node.observe(time.Since(start))
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		node.eject()
+	}
+	return err
+}
+
+// Close stops the health checks and closes every node. Only the first call
+// does anything.
+func (provider *FailoverProvider) Close() error {
+	var err error
+	provider.closeOnce.Do(func() {
+		close(provider.quit)
+		provider.wg.Wait()
+
+		for _, node := range provider.nodes {
+			if cerr := node.provider.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	})
+	return err
+}
+
+// candidates returns the nodes to try for a request, in order: the healthy
+// nodes picked by the policy first, ejected ones last.
+func (provider *FailoverProvider) candidates(params interface{}) []*failoverNode {
+	pool := provider.nodes
+	if provider.options.Policy == PerChain {
+		if chainId, ok := chainIdOf(params); ok {
+			if indexes, ok := provider.options.ChainNodes[chainId]; ok {
+				pool = make([]*failoverNode, 0, len(indexes))
+				for _, i := range indexes {
+					if i >= 0 && i < len(provider.nodes) {
+						pool = append(pool, provider.nodes[i])
+					}
+				}
+			}
+		}
+	}
+	if len(pool) == 0 {
+		return nil
+	}
+
+	type candidate struct {
+		node    *failoverNode
+		healthy bool
+		latency time.Duration
+	}
+	ordered := make([]candidate, len(pool))
+	start := int(atomic.AddUint32(&provider.next, 1)-1) % len(pool)
+	for i := range pool {
+		node := pool[(start+i)%len(pool)]
+		node.mu.Lock()
+		ordered[i] = candidate{node: node, healthy: node.healthy, latency: node.latency}
+		node.mu.Unlock()
+	}
+	if provider.options.Policy == LeastLatency {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].latency < ordered[j].latency
+		})
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].healthy && !ordered[j].healthy
+	})
+
+	nodes := make([]*failoverNode, len(ordered))
+	for i, c := range ordered {
+		nodes[i] = c.node
+	}
+	return nodes
+}
+
+func (provider *FailoverProvider) healthLoop() {
+	defer provider.wg.Done()
+
+	ticker := time.NewTicker(provider.options.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			provider.checkHealth()
+		case <-provider.quit:
+			return
+		}
+	}
+}
+
+func (provider *FailoverProvider) checkHealth() {
+	var wg sync.WaitGroup
+	for _, node := range provider.nodes {
+		wg.Add(1)
+		go func(node *failoverNode) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), provider.options.HealthCheckInterval)
+			defer cancel()
+
+			start := time.Now()
+			if err := provider.options.HealthCheck(ctx, node.provider); err != nil {
+				node.eject()
+				return
+			}
+			node.observe(time.Since(start))
+		}(node)
+	}
+	wg.Wait()
+}
+
+// pingHealthCheck returns a health check that pings chainId.
+func pingHealthCheck(chainId string) func(ctx context.Context, node ProviderInterface) error {
+	return func(ctx context.Context, node ProviderInterface) error {
+		res := make(map[string]interface{})
+		if err := SendRequestContext(ctx, node, &res, "Ping", map[string]string{"chainId": chainId}); err != nil {
+			return err
+		}
+		if msg, ok := res["ErrMsg"].(string); ok && msg != "" {
+			return &NodeError{Method: "Ping", Message: msg}
+		}
+		if _, ok := res["nonce"].(float64); !ok {
+			return &ProviderError{Method: "Ping", Err: fmt.Errorf("no nonce in response %v", res)}
+		}
+		return nil
+	}
+}
+
+var errNoNodes = errors.New("no nodes available")
+
+// IsTransportError reports whether err means the node could not be used, as
+// opposed to the node answering: with an error of its own, a 4xx status or a
+// body that does not decode, none of which another attempt would change.
+func IsTransportError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		switch {
+		case providerErr.StatusCode >= 500:
+			return true
+		case providerErr.StatusCode != 0 || len(providerErr.Body) > 0:
+			return false
+		case errors.Is(providerErr.Err, ErrCircuitOpen) || errors.Is(providerErr.Err, errNoNodes):
+			return true
+		}
+		err = providerErr.Err
+	}
+	var netErr net.Error
+	var closeErr *websocket.CloseError
+	return errors.As(err, &netErr) ||
+		errors.As(err, &closeErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, websocket.ErrBadHandshake) ||
+		errors.Is(err, errWebSocketTimeout)
+}
+
+func (node *failoverNode) observe(latency time.Duration) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	node.healthy = true
+	if node.latency == 0 {
+		node.latency = latency
+	} else {
+		node.latency += time.Duration(latencyWeight * float64(latency-node.latency))
+	}
+}
+
+func (node *failoverNode) eject() {
+	node.mu.Lock()
+	node.healthy = false
+	node.mu.Unlock()
+}
+
+// chainIdOf extracts the chainId field of request params, whether the node
+// expects it as a string or a number.
+func chainIdOf(params interface{}) (string, bool) {
+	if params == nil {
+		return "", false
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return "", false
+	}
+	var fields struct {
+		ChainId json.RawMessage `json:"chainId"`
+	}
+	if err := json.Unmarshal(body, &fields); err != nil || len(fields.ChainId) == 0 {
+		return "", false
+	}
+	chainId := strings.Trim(string(fields.ChainId), `"`)
+	if _, err := strconv.ParseUint(chainId, 10, 32); err != nil {
+		return "", false
+	}
+	return chainId, true
+}
@@ -0,0 +1,202 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFailoverProvider(t *testing.T) {
+	var down, up int32
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&down, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer dead.Close()
+	alive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&up, 1)
+		w.Write([]byte(`{"TXhash":"0x01"}`))
+	}))
+	defer alive.Close()
+	nodes := func() []ProviderInterface {
+		return []ProviderInterface{
+			NewHTTPProvider(strings.TrimPrefix(dead.URL, "http://"), 10, false),
+			NewHTTPProvider(strings.TrimPrefix(alive.URL, "http://"), 10, false),
+		}
+	}
+
+	provider := NewFailoverProvider(nodes(), FailoverOptions{HealthCheckInterval: -1})
+	defer provider.Close()
+
+	// The first request starts on the dead node and a read is retried.
+	res := make(map[string]interface{})
+	if err := provider.SendRequest(&res, "GetAccount", map[string]string{"chainId": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if d, u := atomic.LoadInt32(&down), atomic.LoadInt32(&up); d != 1 || u != 1 {
+		t.Fatalf("dead/alive hits = %d/%d, want 1/1", d, u)
+	}
+
+	// The dead node is ejected, so later requests go to the live one first.
+	for i := 0; i < 4; i++ {
+		if err := provider.SendRequest(&res, "GetAccount", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := atomic.LoadInt32(&down); d != 1 {
+		t.Errorf("ejected node got %d more requests", d-1)
+	}
+
+	// A fresh provider starts on the dead node; SendTx must fail there
+	// rather than be resent.
+	fresh := NewFailoverProvider(nodes(), FailoverOptions{HealthCheckInterval: -1})
+	defer fresh.Close()
+	before := atomic.LoadInt32(&up)
+	if err := fresh.SendRequest(&res, "SendTx", nil); err == nil {
+		t.Fatal("SendTx on a dead node succeeded")
+	}
+	if atomic.LoadInt32(&up) != before {
+		t.Error("SendTx was resent to another node")
+	}
+
+	if err := fresh.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestFailoverKeepsNodesAnsweringBadRequests(t *testing.T) {
+	for name, handler := range map[string]http.HandlerFunc{
+		"4xx": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		},
+		"undecodable": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`<html>not json</html>`))
+		},
+	} {
+		var first, second int32
+		bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&first, 1)
+			handler(w, r)
+		}))
+		good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&second, 1)
+			w.Write([]byte(`{}`))
+		}))
+		provider := NewFailoverProvider([]ProviderInterface{
+			NewHTTPProvider(strings.TrimPrefix(bad.URL, "http://"), 10, false),
+			NewHTTPProvider(strings.TrimPrefix(good.URL, "http://"), 10, false),
+		}, FailoverOptions{HealthCheckInterval: -1})
+
+		res := make(map[string]interface{})
+		if err := provider.SendRequest(&res, "GetAccount", nil); err == nil {
+			t.Errorf("%s: the bad answer was not returned", name)
+		}
+		if f, s := atomic.LoadInt32(&first), atomic.LoadInt32(&second); f != 1 || s != 0 {
+			t.Errorf("%s: hits = %d/%d, want the request tried once", name, f, s)
+		}
+		// The node stays in rotation: round robin comes back to it.
+		provider.SendRequest(&res, "GetAccount", nil)
+		provider.SendRequest(&res, "GetAccount", nil)
+		if f := atomic.LoadInt32(&first); f != 2 {
+			t.Errorf("%s: node was ejected, got %d requests", name, f)
+		}
+		provider.Close()
+		bad.Close()
+		good.Close()
+	}
+}
+
+func TestIsTransportError(t *testing.T) {
+	for err, want := range map[error]bool{
+		&ProviderError{Method: "m", Err: &net.OpError{Op: "dial", Err: errors.New("refused")}}: true,
+		&ProviderError{Method: "m", StatusCode: 502}:                                           true,
+		&ProviderError{Method: "m", Err: io.EOF}:                                               true,
+		&ProviderError{Method: "m", Err: ErrCircuitOpen}:                                       true,
+		&ProviderError{Method: "m", StatusCode: 404}:                                           false,
+		&ProviderError{Method: "m", StatusCode: 200, Body: []byte("x"), Err: errors.New("x")}:  false,
+		&ProviderError{Method: "m", Body: []byte("x"), Err: errors.New("bad json")}:            false,
+		&ProviderError{Method: "m", Err: errors.New("unsupported")}:                            false,
+		&NodeError{Method: "m", Message: "nonce too low"}:                                      false,
+	} {
+		if got := IsTransportError(err); got != want {
+			t.Errorf("IsTransportError(%#v) = %v, want %v", err, got, want)
+		}
+	}
+}
+
+// pingAnswer is a node serving one chain, answering Ping with body and
+// counting the other requests.
+type pingAnswer struct {
+	chainId string
+	body    string
+	calls   int32
+}
+
+func (p *pingAnswer) SendRequest(v interface{}, method string, params interface{}) error {
+	if method != "Ping" {
+		atomic.AddInt32(&p.calls, 1)
+		return json.Unmarshal([]byte(`{}`), v)
+	}
+	if chainId, _ := chainIdOf(params); chainId != p.chainId {
+		return json.Unmarshal([]byte(`{"ErrMsg":"chain not found"}`), v)
+	}
+	return json.Unmarshal([]byte(p.body), v)
+}
+
+func (p *pingAnswer) Close() error { return nil }
+
+func TestPingHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	if err := pingHealthCheck("2")(ctx, &pingAnswer{chainId: "2", body: `{"nonce":7}`}); err != nil {
+		t.Errorf("node serving the chain: %v", err)
+	}
+	if err := pingHealthCheck("0")(ctx, &pingAnswer{chainId: "2", body: `{"nonce":7}`}); err == nil {
+		t.Error("node not serving the pinged chain passed")
+	}
+	for _, body := range []string{`{"nonce":"7"}`, `{}`} {
+		if err := pingHealthCheck("2")(ctx, &pingAnswer{chainId: "2", body: body}); err == nil {
+			t.Errorf("answer %s passed", body)
+		}
+	}
+
+	// Only the node serving the health check's chain stays in rotation.
+	other, serving := &pingAnswer{chainId: "3", body: `{"nonce":7}`}, &pingAnswer{chainId: "2", body: `{"nonce":7}`}
+	provider := NewFailoverProvider([]ProviderInterface{other, serving}, FailoverOptions{HealthCheckChainId: "2", HealthCheckInterval: 10 * time.Millisecond})
+	defer provider.Close()
+	time.Sleep(50 * time.Millisecond)
+	res := make(map[string]interface{})
+	for i := 0; i < 4; i++ {
+		if err := provider.SendRequest(&res, "GetAccount", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if o, s := atomic.LoadInt32(&other.calls), atomic.LoadInt32(&serving.calls); o != 0 || s != 4 {
+		t.Errorf("requests to the other/serving node = %d/%d, want 0/4", o, s)
+	}
+}
+
+func TestChainIdOf(t *testing.T) {
+	for params, want := range map[interface{}]string{
+		&struct {
+			ChainId string `json:"chainId"`
+		}{"2"}: "2",
+		&struct {
+			ChainId int `json:"chainId"`
+		}{3}: "3",
+	} {
+		if got, ok := chainIdOf(params); !ok || got != want {
+			t.Errorf("chainIdOf(%+v) = %q, %v; want %q", params, got, ok, want)
+		}
+	}
+	if _, ok := chainIdOf(map[string][]int{"chainId": {1, 2}}); ok {
+		t.Error("a chainId list must not be routed")
+	}
+}
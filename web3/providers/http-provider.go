@@ -2,10 +2,14 @@ package providers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sync/atomic"
 	"time"
+	"web3.go/web3/constants"
 	"web3.go/web3/providers/util"
 )
 
@@ -14,8 +18,11 @@ type HTTPProvider struct {
 	timeout int32
 	secure  bool
 	client  *http.Client
+	noBatch *int32 // set once the node has been seen not to support batching
 }
 
+const batchMethod = "batch"
+
 func NewHTTPProvider(address string, timeout int32, secure bool) *HTTPProvider {
 	return newHTTPProviderWithClient(address, timeout, secure, &http.Client{
 		Timeout: time.Second * time.Duration(timeout),
@@ -28,24 +35,99 @@ func newHTTPProviderWithClient(address string, timeout int32, secure bool, clien
 	provider.timeout = timeout
 	provider.secure = secure
 	provider.client = client
+	provider.noBatch = new(int32)
 
 	return provider
 }
 
 func (provider HTTPProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	return provider.SendRequestContext(context.Background(), v, method, params)
+}
+
+func (provider HTTPProvider) SendRequestContext(ctx context.Context, v interface{}, method string, params interface{}) error {
 
 	bodyString := util.JsonParam{Method: method, Params: params}
 
+	bufferparams, err := json.Marshal(bodyString)
+	if err != nil {
+		return &ProviderError{Method: method, Err: err}
+	}
+	status, bodyBytes, err := provider.post(ctx, bufferparams)
+	if err != nil {
+		return &ProviderError{Method: method, StatusCode: status, Err: err}
+	}
+	if status != 200 {
+		return &ProviderError{Method: method, StatusCode: status, Body: bodyBytes}
+	}
+	return decodeResult(method, status, bodyBytes, v)
+
+}
+
+// SendBatchContext posts the whole batch as a JSON array. A node that rejects
+// it with 400 or 405, or answers with an object, is taken not to support
+// batching; this batch and every later one is then sent as individual
+// requests. Any other failure is returned, and batching stays on.
+func (provider HTTPProvider) SendBatchContext(ctx context.Context, batch []BatchElem, parallelism int) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	if atomic.LoadInt32(provider.noBatch) != 0 {
+		return sendPipelined(ctx, provider, batch, parallelism)
+	}
+
+	bodies := make([]util.JsonParam, len(batch))
+	for i, elem := range batch {
+		bodies[i] = util.JsonParam{Method: elem.Method, Params: elem.Params}
+	}
+	bufferparams, err := json.Marshal(bodies)
+	if err != nil {
+		return failBatch(batch, &ProviderError{Method: batchMethod, Err: err})
+	}
+	status, bodyBytes, err := provider.post(ctx, bufferparams)
+	if err != nil {
+		return failBatch(batch, &ProviderError{Method: batchMethod, StatusCode: status, Err: err})
+	}
+
+	trimmed := bytes.TrimSpace(bodyBytes)
+	if status == http.StatusBadRequest || status == http.StatusMethodNotAllowed || (status == 200 && len(trimmed) > 0 && trimmed[0] == '{') {
+		atomic.StoreInt32(provider.noBatch, 1)
+		return sendPipelined(ctx, provider, batch, parallelism)
+	}
+	if status != 200 {
+		return failBatch(batch, &ProviderError{Method: batchMethod, StatusCode: status, Body: bodyBytes})
+	}
+	var results []json.RawMessage
+	if err := json.Unmarshal(bodyBytes, &results); err != nil {
+		return failBatch(batch, &ProviderError{Method: batchMethod, StatusCode: status, Body: bodyBytes, Err: err})
+	}
+	if len(results) != len(batch) {
+		return failBatch(batch, &ProviderError{Method: batchMethod, StatusCode: status, Body: bodyBytes, Err: fmt.Errorf("%d results for %d requests", len(results), len(batch))})
+	}
+	for i, result := range results {
+		batch[i].Error = decodeResult(batch[i].Method, status, result, batch[i].Result)
+	}
+	return nil
+}
+
+// failBatch sets err on every request of a batch that failed as a whole and
+// returns it.
+func failBatch(batch []BatchElem, err error) error {
+	for i := range batch {
+		batch[i].Error = err
+	}
+	return err
+}
+
+func (provider HTTPProvider) post(ctx context.Context, body []byte) (int, []byte, error) {
 	prefix := "http://"
 	if provider.secure {
 		prefix = "https://"
 	}
-	bufferparams, err := json.Marshal(bodyString)
-	// body := strings.NewReader(bodyString.AsJsonString())
-	req, err := http.NewRequest("POST", prefix+provider.address, bytes.NewBuffer(bufferparams))
+	req, err := http.NewRequest("POST", prefix+provider.address, bytes.NewBuffer(body))
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
+	req = req.WithContext(ctx)
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
@@ -53,24 +135,23 @@ func (provider HTTPProvider) SendRequest(v interface{}, method string, params in
 	resp, err := provider.client.Do(req)
 
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 
 	defer resp.Body.Close()
 
-	var bodyBytes []byte
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, bodyBytes, err
+}
 
-	if resp.StatusCode == 200 {
-		bodyBytes, err = ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		} else {
-			return json.Unmarshal(bodyBytes, v)
-		}
+func decodeResult(method string, status int, body []byte, v interface{}) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return &ProviderError{Method: method, StatusCode: status, Err: customerror.EMPTYRESPONSE}
 	}
-
-	return json.Unmarshal(bodyBytes, v)
-
+	if err := json.Unmarshal(body, v); err != nil {
+		return &ProviderError{Method: method, StatusCode: status, Body: body, Err: err}
+	}
+	return nil
 }
 
 func (provider HTTPProvider) Close() error {
@@ -0,0 +1,198 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"web3.go/web3/providers/util"
+)
+
+func TestHTTPProviderErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("upstream unavailable"))
+		case "/empty":
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(`{}`))
+		default:
+			w.Write([]byte(`{"nonce":43}`))
+		}
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	res := make(map[string]interface{})
+	if err := NewHTTPProvider(address, 10, false).SendRequest(&res, "GetAccount", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var perr *ProviderError
+	err := NewHTTPProvider(address+"/down", 10, false).SendRequest(&res, "GetAccount", nil)
+	if !errors.As(err, &perr) {
+		t.Fatalf("got %v, want a *ProviderError", err)
+	}
+	if perr.StatusCode != http.StatusBadGateway || string(perr.Body) != "upstream unavailable" || perr.Method != "GetAccount" {
+		t.Errorf("unexpected error fields: %+v", perr)
+	}
+
+	err = NewHTTPProvider(address+"/empty", 10, false).SendRequest(&res, "GetAccount", nil)
+	if !errors.As(err, &perr) || perr.StatusCode != http.StatusOK {
+		t.Errorf("empty body: got %v, want a *ProviderError with status 200", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = NewHTTPProvider(address+"/slow", 10, false).SendRequestContext(ctx, &res, "GetAccount", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestHTTPProviderCancel(t *testing.T) {
+	started := make(chan struct{})
+	aborted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices the client going away once the body is read.
+		io.Copy(io.Discard, r.Body)
+		close(started)
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-time.After(5 * time.Second):
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	start := time.Now()
+	res := make(map[string]interface{})
+	err := NewHTTPProvider(strings.TrimPrefix(server.URL, "http://"), 10, false).SendRequestContext(ctx, &res, "GetAccount", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled request returned after %v", elapsed)
+	}
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Error("the server never saw the request aborted")
+	}
+}
+
+func TestHTTPProviderBatch(t *testing.T) {
+	var single int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var raw json.RawMessage
+		json.NewDecoder(r.Body).Decode(&raw)
+		var reqs []util.JsonParam
+		if json.Unmarshal(raw, &reqs) == nil {
+			switch r.URL.Path {
+			case "/nobatch":
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			case "/object":
+				w.Write([]byte(`{"ErrMsg":"invalid request"}`))
+				return
+			}
+			results := make([]interface{}, len(reqs))
+			for i, req := range reqs {
+				results[i] = map[string]interface{}{"height": req.Params}
+			}
+			json.NewEncoder(w).Encode(results)
+			return
+		}
+		var req util.JsonParam
+		json.Unmarshal(raw, &req)
+		atomic.AddInt32(&single, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"height": req.Params})
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	for _, path := range []string{"", "/nobatch", "/object"} {
+		batch := make([]BatchElem, 20)
+		results := make([]map[string]int, len(batch))
+		for i := range batch {
+			batch[i] = BatchElem{Method: "GetBlockHeader", Params: i, Result: &results[i]}
+		}
+		if err := SendBatchContext(context.Background(), NewHTTPProvider(address+path, 10, false), batch, 4); err != nil {
+			t.Fatal(err)
+		}
+		for i, elem := range batch {
+			if elem.Error != nil || results[i]["height"] != i {
+				t.Errorf("%q: entry %d = %v (%v), want height %d", path, i, results[i], elem.Error, i)
+			}
+		}
+	}
+	if n := atomic.LoadInt32(&single); n != 40 {
+		t.Errorf("fallback sent %d individual requests, want 40", n)
+	}
+}
+
+func TestHTTPProviderBatchTransientFailure(t *testing.T) {
+	var batches, single int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var raw json.RawMessage
+		json.NewDecoder(r.Body).Decode(&raw)
+		var reqs []util.JsonParam
+		if json.Unmarshal(raw, &reqs) != nil {
+			atomic.AddInt32(&single, 1)
+			w.Write([]byte(`{}`))
+			return
+		}
+		switch atomic.AddInt32(&batches, 1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Write([]byte(`not json`))
+		default:
+			w.Write([]byte(`[{}, {}]`))
+		}
+	}))
+	defer server.Close()
+	provider := NewHTTPProvider(strings.TrimPrefix(server.URL, "http://"), 10, false)
+
+	send := func() error {
+		batch := []BatchElem{{Method: "SendTx", Result: &map[string]interface{}{}}, {Method: "SendTx", Result: &map[string]interface{}{}}}
+		err := SendBatchContext(context.Background(), provider, batch, 2)
+		for i, elem := range batch {
+			if elem.Error != err {
+				t.Errorf("entry %d failed with %v, want the batch error %v", i, elem.Error, err)
+			}
+		}
+		return err
+	}
+	var providerErr *ProviderError
+	if err := send(); !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("502: got %v, want a *ProviderError with status 502", err)
+	}
+	if err := send(); !errors.As(err, &providerErr) || len(providerErr.Body) == 0 {
+		t.Errorf("undecodable body: got %v, want a *ProviderError with the body", err)
+	}
+	if err := send(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&single); n != 0 {
+		t.Errorf("failed batches were resent as %d individual requests", n)
+	}
+	if n := atomic.LoadInt32(&batches); n != 3 {
+		t.Errorf("sent %d batches, want 3: batching must stay on", n)
+	}
+}
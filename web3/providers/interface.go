@@ -1,7 +1,34 @@
 package providers
 
+import "context"
 
 type ProviderInterface interface {
 	SendRequest(v interface{}, method string, params interface{}) error
 	Close() error
-}
\ No newline at end of file
+}
+
+// ContextProviderInterface is implemented by providers whose requests can be
+// bounded by a context.
+type ContextProviderInterface interface {
+	ProviderInterface
+	SendRequestContext(ctx context.Context, v interface{}, method string, params interface{}) error
+}
+
+// SubscriptionProviderInterface is implemented by providers that can receive
+// messages pushed by the node.
+type SubscriptionProviderInterface interface {
+	ProviderInterface
+	Subscribe(channel interface{}, method string, params interface{}) (*Subscription, error)
+}
+
+// SendRequestContext sends a request through provider. If the provider does
+// not take a context, ctx is only checked before the request is sent.
+func SendRequestContext(ctx context.Context, provider ProviderInterface, v interface{}, method string, params interface{}) error {
+	if p, ok := provider.(ContextProviderInterface); ok {
+		return p.SendRequestContext(ctx, v, method, params)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return provider.SendRequest(v, method, params)
+}
@@ -0,0 +1,287 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Handler sends one request. It is the unit middlewares wrap.
+type Handler func(ctx context.Context, v interface{}, method string, params interface{}) error
+
+// Middleware decorates a Handler, for instance to retry, throttle or observe
+// the requests passing through it.
+type Middleware func(next Handler) Handler
+
+// MiddlewareProvider is a provider whose requests go through a chain of
+// middlewares before reaching the wrapped provider.
+type MiddlewareProvider struct {
+	provider ProviderInterface
+	handler  Handler
+}
+
+// Wrap decorates provider with middlewares. The first middleware is the
+// outermost one: Wrap(p, Retry(...), RateLimit(...)) rate limits every
+// attempt the retry middleware makes.
+func Wrap(provider ProviderInterface, middlewares ...Middleware) *MiddlewareProvider {
+	handler := Handler(func(ctx context.Context, v interface{}, method string, params interface{}) error {
+		return SendRequestContext(ctx, provider, v, method, params)
+	})
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return &MiddlewareProvider{provider: provider, handler: handler}
+}
+
+func (provider *MiddlewareProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	return provider.SendRequestContext(context.Background(), v, method, params)
+}
+
+func (provider *MiddlewareProvider) SendRequestContext(ctx context.Context, v interface{}, method string, params interface{}) error {
+	return provider.handler(ctx, v, method, params)
+}
+
+func (provider *MiddlewareProvider) Close() error {
+	return provider.provider.Close()
+}
+
+// ErrCircuitOpen is the cause of the ProviderError returned for requests
+// refused by an open circuit breaker.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type RetryOptions struct {
+	// MaxAttempts is the total number of tries, including the first one.
+	// Zero means 3.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry; it doubles after
+	// each attempt up to MaxBackoff. Zero means 100ms and 5s respectively.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Retryable decides whether a failed request is tried again. Zero means
+	// DefaultRetryable.
+	Retryable func(method string, err error) bool
+}
+
+// DefaultRetryable retries transport failures of idempotent methods. Errors
+// reported by the node are final, and SendTx is never resent since the node
+// may have applied the first attempt.
+func DefaultRetryable(method string, err error) bool {
+	return IsIdempotent(method) && IsTransportError(err)
+}
+
+// Retry retries failed requests with exponential backoff and jitter. It gives
+// up early when the request context is done.
+func Retry(options RetryOptions) Middleware {
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = 3
+	}
+	if options.InitialBackoff <= 0 {
+		options.InitialBackoff = 100 * time.Millisecond
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = 5 * time.Second
+	}
+	if options.Retryable == nil {
+		options.Retryable = DefaultRetryable
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, v interface{}, method string, params interface{}) error {
+			backoff := options.InitialBackoff
+			for attempt := 1; ; attempt++ {
+				err := next(ctx, v, method, params)
+				if err == nil || attempt == options.MaxAttempts || ctx.Err() != nil || !options.Retryable(method, err) {
+					return err
+				}
+				// Sleep for a random duration in [backoff/2, backoff) so
+				// clients failing together don't retry in lockstep.
+				wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return err
+				}
+				if backoff *= 2; backoff > options.MaxBackoff {
+					backoff = options.MaxBackoff
+				}
+			}
+		}
+	}
+}
+
+// RateLimit throttles requests with a token bucket refilled at perSecond
+// tokens a second and holding at most burst tokens. Requests wait for a token
+// or until their context is done.
+func RateLimit(perSecond float64, burst int) Middleware {
+	if burst < 1 {
+		burst = 1
+	}
+	bucket := &tokenBucket{rate: perSecond, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, v interface{}, method string, params interface{}) error {
+			if err := bucket.wait(ctx); err != nil {
+				return err
+			}
+			return next(ctx, v, method, params)
+		}
+	}
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait takes a token, sleeping until it is available. The token is reserved
+// up front, leaving the bucket negative, so waiters are served in order.
+func (bucket *tokenBucket) wait(ctx context.Context) error {
+	bucket.mu.Lock()
+	now := time.Now()
+	bucket.tokens += now.Sub(bucket.last).Seconds() * bucket.rate
+	if bucket.tokens > bucket.burst {
+		bucket.tokens = bucket.burst
+	}
+	bucket.last = now
+	bucket.tokens--
+	deficit := -bucket.tokens
+	bucket.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+	if bucket.rate <= 0 {
+		<-ctx.Done()
+		bucket.refund()
+		return ctx.Err()
+	}
+	timer := time.NewTimer(time.Duration(deficit / bucket.rate * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		bucket.refund()
+		return ctx.Err()
+	}
+}
+
+func (bucket *tokenBucket) refund() {
+	bucket.mu.Lock()
+	bucket.tokens++
+	bucket.mu.Unlock()
+}
+
+// ConcurrencyLimit bounds the requests in flight to max. Requests over the
+// limit wait for a slot or until their context is done.
+func ConcurrencyLimit(max int) Middleware {
+	if max < 1 {
+		max = 1
+	}
+	sem := make(chan struct{}, max)
+	return func(next Handler) Handler {
+		return func(ctx context.Context, v interface{}, method string, params interface{}) error {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer func() { <-sem }()
+			return next(ctx, v, method, params)
+		}
+	}
+}
+
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit. Zero means 5.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before a single request
+	// is let through to probe the node. Zero means 30s.
+	OpenTimeout time.Duration
+	// IsFailure decides whether an error counts against the node. Zero means
+	// transport failures count and errors reported by the node don't.
+	IsFailure func(err error) bool
+}
+
+// CircuitBreaker stops sending requests to a node that keeps failing. While
+// the circuit is open requests fail at once with ErrCircuitOpen; after
+// OpenTimeout one request probes the node and closes the circuit if it
+// succeeds.
+func CircuitBreaker(options CircuitBreakerOptions) Middleware {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = 5
+	}
+	if options.OpenTimeout <= 0 {
+		options.OpenTimeout = 30 * time.Second
+	}
+	if options.IsFailure == nil {
+		options.IsFailure = IsTransportError
+	}
+	breaker := &circuitBreaker{options: options}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, v interface{}, method string, params interface{}) error {
+			probe, ok := breaker.allow()
+			if !ok {
+				return &ProviderError{Method: method, Err: ErrCircuitOpen}
+			}
+			err := next(ctx, v, method, params)
+			// A request abandoned by its caller says nothing about the node.
+			failed := err != nil && ctx.Err() == nil && options.IsFailure(err)
+			breaker.record(probe, failed, err != nil && ctx.Err() != nil)
+			return err
+		}
+	}
+}
+
+type circuitBreaker struct {
+	options CircuitBreakerOptions
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // zero while closed
+	probing  bool
+}
+
+// allow reports whether a request may be sent, and whether it is the probe of
+// a half-open circuit.
+func (breaker *circuitBreaker) allow() (probe bool, ok bool) {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	if breaker.openedAt.IsZero() {
+		return false, true
+	}
+	if breaker.probing || time.Since(breaker.openedAt) < breaker.options.OpenTimeout {
+		return false, false
+	}
+	breaker.probing = true
+	return true, true
+}
+
+func (breaker *circuitBreaker) record(probe bool, failed bool, abandoned bool) {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	if probe {
+		breaker.probing = false
+	}
+	switch {
+	case abandoned:
+		// Leave the state as it was; an abandoned probe lets the next
+		// request probe instead.
+	case failed:
+		breaker.failures++
+		if probe || breaker.failures >= breaker.options.FailureThreshold {
+			breaker.openedAt = time.Now()
+		}
+	default:
+		breaker.failures = 0
+		breaker.openedAt = time.Time{}
+	}
+}
@@ -0,0 +1,126 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var connRefused = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+// funcProvider answers requests with a function, counting the calls.
+type funcProvider struct {
+	calls int32
+	fn    func(method string) error
+}
+
+func (p *funcProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	atomic.AddInt32(&p.calls, 1)
+	return p.fn(method)
+}
+
+func (p *funcProvider) Close() error { return nil }
+
+func TestRetry(t *testing.T) {
+	down := &ProviderError{Method: "GetAccount", Err: connRefused}
+	retry := Retry(RetryOptions{MaxAttempts: 4, InitialBackoff: time.Millisecond})
+
+	node := &funcProvider{fn: func(string) error { return down }}
+	provider := Wrap(node, retry)
+	if err := provider.SendRequest(nil, "GetAccount", nil); err != down {
+		t.Errorf("got %v, want %v", err, down)
+	}
+	if node.calls != 4 {
+		t.Errorf("GetAccount tried %d times, want 4", node.calls)
+	}
+
+	node.calls = 0
+	provider.SendRequest(nil, "SendTx", nil)
+	if node.calls != 1 {
+		t.Errorf("SendTx tried %d times, want 1", node.calls)
+	}
+
+	node = &funcProvider{fn: func(string) error { return &NodeError{Message: "nonce too low"} }}
+	Wrap(node, retry).SendRequest(nil, "GetAccount", nil)
+	if node.calls != 1 {
+		t.Errorf("node error retried: tried %d times, want 1", node.calls)
+	}
+}
+
+func TestRateLimitAndConcurrency(t *testing.T) {
+	var inFlight, peak int32
+	node := &funcProvider{fn: func(string) error {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return nil
+	}}
+	provider := Wrap(node, RateLimit(200, 5), ConcurrencyLimit(2))
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 15; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			provider.SendRequest(nil, "GetAccount", nil)
+		}()
+	}
+	wg.Wait()
+	// 5 requests use the burst, the other 10 wait 5ms each for a token.
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("15 requests took %v, want at least 40ms at 200/s with a burst of 5", elapsed)
+	}
+	if peak > 2 {
+		t.Errorf("%d requests in flight, want at most 2", peak)
+	}
+
+	limited := Wrap(node, RateLimit(0, 1))
+	limited.SendRequest(nil, "GetAccount", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limited.SendRequestContext(ctx, nil, "GetAccount", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded once the burst is used", err)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var failing atomic.Value
+	failing.Store(true)
+	node := &funcProvider{fn: func(string) error {
+		if failing.Load().(bool) {
+			return &ProviderError{Method: "GetAccount", Err: connRefused}
+		}
+		return nil
+	}}
+	provider := Wrap(node, CircuitBreaker(CircuitBreakerOptions{FailureThreshold: 3, OpenTimeout: 20 * time.Millisecond}))
+
+	for i := 0; i < 5; i++ {
+		provider.SendRequest(nil, "GetAccount", nil)
+	}
+	if node.calls != 3 {
+		t.Errorf("node called %d times, want 3 before the circuit opens", node.calls)
+	}
+	if err := provider.SendRequest(nil, "GetAccount", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("got %v, want ErrCircuitOpen", err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	failing.Store(false)
+	if err := provider.SendRequest(nil, "GetAccount", nil); err != nil {
+		t.Errorf("probe: %v", err)
+	}
+	if err := provider.SendRequest(nil, "GetAccount", nil); err != nil {
+		t.Errorf("after probe: %v", err)
+	}
+}
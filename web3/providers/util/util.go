@@ -1,6 +1,19 @@
 package util
 
+import "encoding/json"
+
 type JsonParam struct {
+	Id     uint64      `json:"id,omitempty"`
 	Method string      `json:"method"`
 	Params interface{} `json:"params"`
 }
+
+// JsonMessage is a frame read from a streaming (websocket) connection. A reply
+// to a request carries the request Id, a server push carries the Subscription
+// id it belongs to.
+type JsonMessage struct {
+	Id           uint64          `json:"id,omitempty"`
+	Subscription string          `json:"subscription,omitempty"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
@@ -0,0 +1,550 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"web3.go/web3/constants"
+	"web3.go/web3/providers/util"
+)
+
+const (
+	// UnsubscribeMethod is sent to the node when a subscription is cancelled.
+	UnsubscribeMethod = "Unsubscribe"
+
+	wsReconnectMinDelay = 500 * time.Millisecond
+	wsReconnectMaxDelay = 30 * time.Second
+)
+
+var (
+	errWebSocketClosed  = errors.New("websocket provider closed")
+	errWebSocketTimeout = errors.New("timed out")
+)
+
+// WebSocketProvider talks to a node over a single websocket connection.
+//
+// Every request is framed as util.JsonParam with a unique id and answered by a
+// util.JsonMessage carrying the same id. A subscription request is answered
+// with the subscription id as its result, after which the node pushes
+// util.JsonMessage frames tagged with that id. When the connection drops the
+// provider redials in the background and re-issues every live subscription.
+type WebSocketProvider struct {
+	address string
+	timeout int32
+	secure  bool
+	dialer  *websocket.Dialer
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	nextId  uint64
+	pending map[uint64]*wsRequest
+	subs    map[string]*Subscription
+	closed  bool
+	closing chan struct{}
+	dialing chan struct{} // closed when the dial in progress ends
+
+	writeMu sync.Mutex
+}
+
+type wsRequest struct {
+	sub   *Subscription
+	reply chan wsReply
+}
+
+type wsReply struct {
+	msg *util.JsonMessage
+	err error
+}
+
+func NewWebSocketProvider(address string, timeout int32, secure bool) *WebSocketProvider {
+	return newWebSocketProviderWithDialer(address, timeout, secure, &websocket.Dialer{
+		HandshakeTimeout: time.Second * time.Duration(timeout),
+	})
+}
+
+func newWebSocketProviderWithDialer(address string, timeout int32, secure bool, dialer *websocket.Dialer) *WebSocketProvider {
+	provider := new(WebSocketProvider)
+	provider.address = address
+	provider.timeout = timeout
+	provider.secure = secure
+	provider.dialer = dialer
+	provider.pending = make(map[uint64]*wsRequest)
+	provider.subs = make(map[string]*Subscription)
+	provider.closing = make(chan struct{})
+
+	return provider
+}
+
+func (provider *WebSocketProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	return provider.SendRequestContext(context.Background(), v, method, params)
+}
+
+func (provider *WebSocketProvider) SendRequestContext(ctx context.Context, v interface{}, method string, params interface{}) error {
+	msg, err := provider.call(ctx, method, params, nil)
+	if err != nil {
+		return &ProviderError{Method: method, Err: err}
+	}
+	if msg.Error != "" {
+		return &NodeError{Method: method, Message: msg.Error}
+	}
+	if len(msg.Result) == 0 {
+		return &ProviderError{Method: method, Err: customerror.EMPTYRESPONSE}
+	}
+	if err := json.Unmarshal(msg.Result, v); err != nil {
+		return &ProviderError{Method: method, Body: msg.Result, Err: err}
+	}
+	return nil
+}
+
+// Subscribe sends method to the node and forwards every message it pushes for
+// the resulting subscription to channel, which must be a writable channel. The
+// messages are decoded into the channel's element type.
+func (provider *WebSocketProvider) Subscribe(channel interface{}, method string, params interface{}) (*Subscription, error) {
+	chanVal := reflect.ValueOf(channel)
+	if chanVal.Kind() != reflect.Chan || chanVal.Type().ChanDir()&reflect.SendDir == 0 {
+		return nil, fmt.Errorf("subscribe: channel argument of type %T is not a writable channel", channel)
+	}
+	sub := &Subscription{
+		provider: provider,
+		method:   method,
+		params:   params,
+		channel:  chanVal,
+		in:       make(chan json.RawMessage),
+		err:      make(chan error, 1),
+		quit:     make(chan struct{}),
+	}
+	go sub.forward()
+
+	if _, err := provider.subscribe(sub); err != nil {
+		sub.Unsubscribe()
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (provider *WebSocketProvider) Close() error {
+	provider.mu.Lock()
+	if provider.closed {
+		provider.mu.Unlock()
+		return nil
+	}
+	provider.closed = true
+	close(provider.closing)
+	conn := provider.conn
+	provider.conn = nil
+	subs := provider.subs
+	provider.subs = make(map[string]*Subscription)
+	provider.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.stop()
+	}
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+func (provider *WebSocketProvider) url() string {
+	prefix := "ws://"
+	if provider.secure {
+		prefix = "wss://"
+	}
+	return prefix + provider.address
+}
+
+// connect returns the live connection, dialling a new one if there is none.
+// The dial runs without mu held, so that other requests and Close don't wait
+// for it; concurrent callers wait for the one dial in progress instead of
+// starting their own.
+func (provider *WebSocketProvider) connect(ctx context.Context) (*websocket.Conn, error) {
+	for {
+		provider.mu.Lock()
+		if provider.closed {
+			provider.mu.Unlock()
+			return nil, errWebSocketClosed
+		}
+		if provider.conn != nil {
+			conn := provider.conn
+			provider.mu.Unlock()
+			return conn, nil
+		}
+		if dialing := provider.dialing; dialing != nil {
+			provider.mu.Unlock()
+			select {
+			case <-dialing:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-provider.closing:
+				return nil, errWebSocketClosed
+			}
+		}
+		dialing := make(chan struct{})
+		provider.dialing = dialing
+		provider.mu.Unlock()
+
+		conn, err := provider.dial(ctx)
+
+		provider.mu.Lock()
+		provider.dialing = nil
+		close(dialing)
+		if err != nil {
+			provider.mu.Unlock()
+			return nil, err
+		}
+		if provider.closed {
+			provider.mu.Unlock()
+			conn.Close()
+			return nil, errWebSocketClosed
+		}
+		provider.conn = conn
+		provider.mu.Unlock()
+
+		go provider.read(conn)
+		return conn, nil
+	}
+}
+
+// dial opens a new connection, giving up when ctx is done or the provider is
+// closed. The dialer doesn't stop a handshake in progress for either, so the
+// dial is left to finish in the background and its connection closed.
+func (provider *WebSocketProvider) dial(ctx context.Context) (*websocket.Conn, error) {
+	type dialed struct {
+		conn *websocket.Conn
+		err  error
+	}
+	done := make(chan dialed, 1)
+	go func() {
+		conn, _, err := provider.dialer.DialContext(ctx, provider.url(), nil)
+		done <- dialed{conn, err}
+	}()
+	abandon := func() {
+		if d := <-done; d.conn != nil {
+			d.conn.Close()
+		}
+	}
+	select {
+	case d := <-done:
+		return d.conn, d.err
+	case <-ctx.Done():
+		go abandon()
+		return nil, ctx.Err()
+	case <-provider.closing:
+		go abandon()
+		return nil, errWebSocketClosed
+	}
+}
+
+// call sends a request and waits for the matching reply. When sub is set the
+// subscription is registered under the returned id before any push for it is
+// dispatched.
+func (provider *WebSocketProvider) call(ctx context.Context, method string, params interface{}, sub *Subscription) (*util.JsonMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	conn, err := provider.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	provider.mu.Lock()
+	if provider.conn != conn {
+		provider.mu.Unlock()
+		return nil, customerror.WEBSOCKETNOTDENIFIED
+	}
+	provider.nextId++
+	id := provider.nextId
+	req := &wsRequest{sub: sub, reply: make(chan wsReply, 1)}
+	provider.pending[id] = req
+	provider.mu.Unlock()
+
+	provider.writeMu.Lock()
+	err = conn.WriteJSON(util.JsonParam{Id: id, Method: method, Params: params})
+	provider.writeMu.Unlock()
+	if err != nil {
+		provider.forget(id)
+		return nil, err
+	}
+
+	var timeout <-chan time.Time
+	if provider.timeout > 0 {
+		timer := time.NewTimer(time.Second * time.Duration(provider.timeout))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case reply := <-req.reply:
+		return reply.msg, reply.err
+	case <-timeout:
+		provider.forget(id)
+		return nil, fmt.Errorf("websocket request %s %w", method, errWebSocketTimeout)
+	case <-ctx.Done():
+		provider.forget(id)
+		return nil, ctx.Err()
+	case <-provider.closing:
+		return nil, errWebSocketClosed
+	}
+}
+
+func (provider *WebSocketProvider) forget(id uint64) {
+	provider.mu.Lock()
+	delete(provider.pending, id)
+	provider.mu.Unlock()
+}
+
+func (provider *WebSocketProvider) read(conn *websocket.Conn) {
+	for {
+		msg := new(util.JsonMessage)
+		if err := conn.ReadJSON(msg); err != nil {
+			provider.drop(conn, err)
+			return
+		}
+		provider.dispatch(msg)
+	}
+}
+
+func (provider *WebSocketProvider) dispatch(msg *util.JsonMessage) {
+	provider.mu.Lock()
+	if msg.Subscription != "" {
+		sub := provider.subs[msg.Subscription]
+		provider.mu.Unlock()
+		if sub != nil {
+			sub.deliver(msg.Result)
+		}
+		return
+	}
+	req, ok := provider.pending[msg.Id]
+	delete(provider.pending, msg.Id)
+	if ok && req.sub != nil && msg.Error == "" {
+		var subId string
+		if err := json.Unmarshal(msg.Result, &subId); err == nil && subId != "" {
+			req.sub.id = subId
+			provider.subs[subId] = req.sub
+		}
+	}
+	provider.mu.Unlock()
+
+	if ok {
+		req.reply <- wsReply{msg: msg}
+	}
+}
+
+// drop tears down a broken connection, fails its outstanding requests and, if
+// there are live subscriptions, starts re-establishing them.
+func (provider *WebSocketProvider) drop(conn *websocket.Conn, cause error) {
+	provider.mu.Lock()
+	if provider.conn != conn {
+		provider.mu.Unlock()
+		return
+	}
+	provider.conn = nil
+	pending := provider.pending
+	provider.pending = make(map[uint64]*wsRequest)
+	subs := make([]*Subscription, 0, len(provider.subs))
+	for _, sub := range provider.subs {
+		sub.id = ""
+		subs = append(subs, sub)
+	}
+	provider.subs = make(map[string]*Subscription)
+	closed := provider.closed
+	provider.mu.Unlock()
+
+	conn.Close()
+	for _, req := range pending {
+		req.reply <- wsReply{err: cause}
+	}
+	if !closed && len(subs) > 0 {
+		go provider.resubscribe(subs)
+	}
+}
+
+func (provider *WebSocketProvider) resubscribe(subs []*Subscription) {
+	delay := wsReconnectMinDelay
+	for len(subs) > 0 {
+		var retry []*Subscription
+		for _, sub := range subs {
+			if sub.stopped() {
+				continue
+			}
+			rejected, err := provider.subscribe(sub)
+			if err == nil {
+				continue
+			}
+			if rejected {
+				sub.fail(err)
+				sub.stop()
+				continue
+			}
+			retry = append(retry, sub)
+		}
+		if len(retry) == 0 {
+			return
+		}
+		subs = retry
+
+		select {
+		case <-time.After(delay):
+		case <-provider.closing:
+			return
+		}
+		if delay *= 2; delay > wsReconnectMaxDelay {
+			delay = wsReconnectMaxDelay
+		}
+	}
+}
+
+// subscribe issues the subscription request for sub. rejected reports whether
+// the node itself refused it, as opposed to a transport failure.
+func (provider *WebSocketProvider) subscribe(sub *Subscription) (rejected bool, err error) {
+	msg, err := provider.call(context.Background(), sub.method, sub.params, sub)
+	if err != nil {
+		return false, err
+	}
+	if msg.Error != "" {
+		return true, &NodeError{Method: sub.method, Message: msg.Error}
+	}
+	provider.mu.Lock()
+	id := sub.id
+	provider.mu.Unlock()
+	if id == "" {
+		return true, fmt.Errorf("subscribe: %s did not return a subscription id", sub.method)
+	}
+	return false, nil
+}
+
+func (provider *WebSocketProvider) unsubscribe(sub *Subscription) {
+	provider.mu.Lock()
+	id := sub.id
+	if id != "" {
+		delete(provider.subs, id)
+	}
+	live := provider.conn != nil && !provider.closed
+	provider.mu.Unlock()
+
+	if id != "" && live {
+		provider.call(context.Background(), UnsubscribeMethod, map[string]string{"subscription": id}, nil)
+	}
+}
+
+// Subscription is a stream of messages pushed by the node. It survives
+// reconnects of the underlying provider.
+type Subscription struct {
+	provider *WebSocketProvider
+	method   string
+	params   interface{}
+	id       string // guarded by provider.mu
+
+	channel reflect.Value
+	in      chan json.RawMessage
+	err     chan error
+	quit    chan struct{}
+	once    sync.Once
+
+	errMu     sync.Mutex
+	errClosed bool
+}
+
+// Err returns a channel that receives errors that end or disturb the
+// subscription, such as the node refusing to re-subscribe after a reconnect or
+// a message that can't be decoded. It is closed by Unsubscribe.
+func (sub *Subscription) Err() <-chan error {
+	return sub.err
+}
+
+// Unsubscribe stops delivery and cancels the subscription on the node.
+func (sub *Subscription) Unsubscribe() {
+	if sub.stop() {
+		sub.provider.unsubscribe(sub)
+	}
+}
+
+func (sub *Subscription) stop() bool {
+	stopped := false
+	sub.once.Do(func() {
+		close(sub.quit)
+		sub.errMu.Lock()
+		close(sub.err)
+		sub.errClosed = true
+		sub.errMu.Unlock()
+		stopped = true
+	})
+	return stopped
+}
+
+func (sub *Subscription) stopped() bool {
+	select {
+	case <-sub.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+func (sub *Subscription) fail(err error) {
+	sub.errMu.Lock()
+	defer sub.errMu.Unlock()
+	if sub.errClosed {
+		return
+	}
+	select {
+	case sub.err <- err:
+	default:
+	}
+}
+
+func (sub *Subscription) deliver(result json.RawMessage) {
+	select {
+	case sub.in <- result:
+	case <-sub.quit:
+	}
+}
+
+// forward queues pushed messages so a slow reader never stalls the
+// connection, and hands them to the user channel in order.
+func (sub *Subscription) forward() {
+	var (
+		queue []json.RawMessage
+		head  reflect.Value
+	)
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.quit)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.in)},
+		{Dir: reflect.SelectSend, Chan: sub.channel},
+	}
+	for {
+		for !head.IsValid() && len(queue) > 0 {
+			value := reflect.New(sub.channel.Type().Elem())
+			if err := json.Unmarshal(queue[0], value.Interface()); err != nil {
+				sub.fail(err)
+			} else {
+				head = value.Elem()
+			}
+			if !head.IsValid() {
+				queue = queue[1:]
+			}
+		}
+
+		n := len(cases) - 1
+		if head.IsValid() {
+			cases[2].Send = head
+			n = len(cases)
+		}
+		chosen, recv, _ := reflect.Select(cases[:n])
+		switch chosen {
+		case 0:
+			return
+		case 1:
+			queue = append(queue, recv.Interface().(json.RawMessage))
+		case 2:
+			queue = queue[1:]
+			head = reflect.Value{}
+		}
+	}
+}
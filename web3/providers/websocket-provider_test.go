@@ -0,0 +1,194 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"web3.go/web3/providers/util"
+)
+
+// wsStandIn is a minimal in-process node: it answers GetAccount, never
+// answers Hang, and for SubscribeBlockHeader hands out a fresh subscription
+// id and pushes one header.
+type wsStandIn struct {
+	mu     sync.Mutex
+	conns  []*websocket.Conn
+	nextId int
+	height int
+}
+
+func (s *wsStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.conns = append(s.conns, conn)
+	s.mu.Unlock()
+
+	for {
+		var req util.JsonParam
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		switch req.Method {
+		case "GetAccount":
+			conn.WriteJSON(map[string]interface{}{
+				"id":     req.Id,
+				"result": map[string]interface{}{"address": "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", "nonce": 43},
+			})
+		case "Hang":
+			// never answered
+		case "SubscribeBlockHeader":
+			s.mu.Lock()
+			s.nextId++
+			s.height++
+			subId, height := fmt.Sprintf("sub-%d", s.nextId), s.height
+			s.mu.Unlock()
+			conn.WriteJSON(map[string]interface{}{"id": req.Id, "result": subId})
+			conn.WriteJSON(map[string]interface{}{"subscription": subId, "result": map[string]interface{}{"chainid": 2, "height": height}})
+		default:
+			conn.WriteJSON(map[string]interface{}{"id": req.Id, "error": "unknown method " + req.Method})
+		}
+	}
+}
+
+func (s *wsStandIn) dropAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func TestWebSocketProviderSendRequest(t *testing.T) {
+	server := httptest.NewServer(new(wsStandIn))
+	defer server.Close()
+
+	provider := NewWebSocketProvider(strings.TrimPrefix(server.URL, "http://"), 5, false)
+	defer provider.Close()
+
+	res := make(map[string]interface{})
+	if err := provider.SendRequest(&res, "GetAccount", nil); err != nil {
+		t.Fatal(err)
+	}
+	if res["nonce"].(float64) != 43 {
+		t.Errorf("nonce = %v, want 43", res["nonce"])
+	}
+	if err := provider.SendRequest(&res, "Nope", nil); err == nil {
+		t.Error("expected an error for an unknown method")
+	}
+}
+
+func TestWebSocketProviderCancel(t *testing.T) {
+	server := httptest.NewServer(new(wsStandIn))
+	defer server.Close()
+
+	provider := NewWebSocketProvider(strings.TrimPrefix(server.URL, "http://"), 5, false)
+	defer provider.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	res := make(map[string]interface{})
+	err := provider.SendRequestContext(ctx, &res, "Hang", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled request returned after %v", elapsed)
+	}
+
+	// The connection stays usable for later requests.
+	if err := provider.SendRequest(&res, "GetAccount", nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebSocketProviderCloseDuringDial(t *testing.T) {
+	// A node that accepts connections but never completes the handshake.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	provider := NewWebSocketProvider(listener.Addr().String(), 5, false)
+	errc := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			res := make(map[string]interface{})
+			errc <- provider.SendRequest(&res, "GetAccount", nil)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	provider.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close waited %v for the dial", elapsed)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errc:
+			if err == nil {
+				t.Error("request succeeded without a connection")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("a request kept dialling after Close")
+		}
+	}
+}
+
+func TestWebSocketProviderResubscribe(t *testing.T) {
+	standIn := new(wsStandIn)
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	provider := NewWebSocketProvider(strings.TrimPrefix(server.URL, "http://"), 5, false)
+	defer provider.Close()
+
+	type header struct {
+		Height int `json:"height"`
+	}
+	headers := make(chan header)
+	sub, err := provider.Subscribe(headers, "SubscribeBlockHeader", map[string]string{"chainId": "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	for want := 1; want <= 2; want++ {
+		select {
+		case h := <-headers:
+			if h.Height != want {
+				t.Fatalf("height = %d, want %d", h.Height, want)
+			}
+		case err := <-sub.Err():
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for header %d", want)
+		}
+		// Kill the connection; the provider must redial and re-subscribe.
+		standIn.dropAll()
+	}
+}
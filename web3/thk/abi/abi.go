@@ -10,8 +10,11 @@ import (
 
 type ABI struct {
 	Constructor Method
+	Fallback    Method // set if HasFallback
+	Receive     Method // set if HasReceive
 	Methods     map[string]Method
 	Events      map[string]Event
+	Errors      map[string]Error
 }
 
 func JSON(reader io.Reader) (ABI, error) {
@@ -33,9 +36,9 @@ func (abi ABI) Pack(name string, args ...interface{}) ([]byte, error) {
 		}
 		return arguments, nil
 	}
-	method, exist := abi.Methods[name]
-	if !exist {
-		return nil, fmt.Errorf("method '%s' not found", name)
+	method, err := abi.ResolveMethod(name, args...)
+	if err != nil {
+		return nil, err
 	}
 	arguments, err := method.Inputs.Pack(args...)
 	if err != nil {
@@ -50,7 +53,7 @@ func (abi ABI) Unpack(v interface{}, name string, output []byte) (err error) {
 	}
 	// since there can't be naming collisions with contracts and events,
 	// we need to decide whether we're calling a method or an event
-	if method, ok := abi.Methods[name]; ok {
+	if method, ok := abi.method(name); ok {
 		if len(output)%32 != 0 {
 			return fmt.Errorf("abi: improperly formatted output: %s - Bytes: [%+v]", string(output), output)
 		}
@@ -67,7 +70,7 @@ func (abi ABI) UnpackIntoMap(v map[string]interface{}, name string, data []byte)
 	}
 	// since there can't be naming collisions with contracts and events,
 	// we need to decide whether we're calling a method or an event
-	if method, ok := abi.Methods[name]; ok {
+	if method, ok := abi.method(name); ok {
 		if len(data)%32 != 0 {
 			return fmt.Errorf("abi: improperly formatted output")
 		}
@@ -79,15 +82,20 @@ func (abi ABI) UnpackIntoMap(v map[string]interface{}, name string, data []byte)
 	return fmt.Errorf("abi: could not locate named method or event")
 }
 
+// field is an entry of the JSON ABI.
+type field struct {
+	Type            string     `json:"type"`
+	Name            string     `json:"name,omitempty"`
+	Constant        bool       `json:"constant,omitempty"`
+	Payable         bool       `json:"payable,omitempty"`
+	StateMutability string     `json:"stateMutability,omitempty"`
+	Anonymous       bool       `json:"anonymous,omitempty"`
+	Inputs          []Argument `json:"inputs"`
+	Outputs         []Argument `json:"outputs,omitempty"`
+}
+
 func (abi *ABI) UnmarshalJSON(data []byte) error {
-	var fields []struct {
-		Type      string
-		Name      string
-		Constant  bool
-		Anonymous bool
-		Inputs    []Argument
-		Outputs   []Argument
-	}
+	var fields []field
 
 	if err := json.Unmarshal(data, &fields); err != nil {
 		return err
@@ -95,32 +103,112 @@ func (abi *ABI) UnmarshalJSON(data []byte) error {
 
 	abi.Methods = make(map[string]Method)
 	abi.Events = make(map[string]Event)
+	abi.Errors = make(map[string]Error)
 	for _, field := range fields {
+		method := Method{
+			Name:            field.Name,
+			StateMutability: field.StateMutability,
+			Const:           field.Constant,
+			Payable:         field.Payable,
+			Inputs:          field.Inputs,
+			Outputs:         field.Outputs,
+		}
 		switch field.Type {
 		case "constructor":
-			abi.Constructor = Method{
-				Inputs: field.Inputs,
-			}
+			method.Type = Constructor
+			abi.Constructor = method
+		case "fallback":
+			method.Type = Fallback
+			abi.Fallback = method
+		case "receive":
+			method.Type = Receive
+			abi.Receive = method
 		// empty defaults to function according to the abi spec
 		case "function", "":
-			abi.Methods[field.Name] = Method{
-				Name:    field.Name,
-				Const:   field.Constant,
-				Inputs:  field.Inputs,
-				Outputs: field.Outputs,
-			}
+			abi.addMethod(method)
 		case "event":
-			abi.Events[field.Name] = Event{
+			abi.addEvent(Event{
 				Name:      field.Name,
 				Anonymous: field.Anonymous,
 				Inputs:    field.Inputs,
-			}
+			})
+		case "error":
+			abi.addError(Error{
+				Name:   field.Name,
+				Inputs: field.Inputs,
+			})
+		default:
+			// Skip entry types added to the JSON ABI after this package.
 		}
 	}
 
 	return nil
 }
 
+// MarshalJSON writes the ABI back in the JSON form UnmarshalJSON reads:
+// the constructor, fallback and receive functions, the methods, events and
+// errors, with overloads in the order their keys were given in.
+func (abi ABI) MarshalJSON() ([]byte, error) {
+	// functionField writes outputs even when a function returns nothing,
+	// which the omitempty on field.Outputs would drop.
+	type functionField struct {
+		field
+		Outputs []Argument `json:"outputs"`
+	}
+	var fields []interface{}
+	methodField := func(typ string, method Method) field {
+		return field{
+			Type:            typ,
+			Name:            method.RawName,
+			Constant:        method.Const,
+			Payable:         method.Payable,
+			StateMutability: method.StateMutability,
+			Inputs:          nonNil(method.Inputs),
+			Outputs:         method.Outputs,
+		}
+	}
+	if abi.Constructor.Type == Constructor {
+		fields = append(fields, methodField("constructor", abi.Constructor))
+	}
+	if abi.HasFallback() {
+		fields = append(fields, methodField("fallback", abi.Fallback))
+	}
+	if abi.HasReceive() {
+		fields = append(fields, methodField("receive", abi.Receive))
+	}
+	for _, name := range sortedOverloads(abi.Methods) {
+		method := abi.Methods[name]
+		method.RawName = method.rawName()
+		fields = append(fields, functionField{methodField("function", method), nonNil(method.Outputs)})
+	}
+	for _, name := range sortedOverloads(abi.Events) {
+		event := abi.Events[name]
+		fields = append(fields, field{Type: "event", Name: event.rawName(), Anonymous: event.Anonymous, Inputs: nonNil(event.Inputs)})
+	}
+	for _, name := range sortedOverloads(abi.Errors) {
+		e := abi.Errors[name]
+		fields = append(fields, field{Type: "error", Name: e.rawName(), Inputs: nonNil(e.Inputs)})
+	}
+	return json.Marshal(fields)
+}
+
+// HasFallback reports whether the contract declares a fallback function.
+func (abi ABI) HasFallback() bool {
+	return abi.Fallback.Type == Fallback
+}
+
+// HasReceive reports whether the contract declares a receive function.
+func (abi ABI) HasReceive() bool {
+	return abi.Receive.Type == Receive
+}
+
+func nonNil(arguments Arguments) Arguments {
+	if arguments == nil {
+		return Arguments{}
+	}
+	return arguments
+}
+
 func (abi *ABI) MethodById(sigdata []byte) (*Method, error) {
 	if len(sigdata) < 4 {
 		return nil, fmt.Errorf("data too short (%d bytes) for abi method lookup", len(sigdata))
@@ -146,7 +234,7 @@ func (abi ABI) InputUnpack(v map[string]interface{}, name string, inputParam []b
 	}
 
 	// 得到abi的方法信息
-	if method, ok := abi.Methods[name]; ok {
+	if method, ok := abi.method(name); ok {
 
 		return method.Inputs.UnpackIntoMap(v, inputParam)
 	}
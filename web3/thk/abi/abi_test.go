@@ -1,11 +1,9 @@
 package abi
 
 import (
-	"bytes"
 	"encoding/hex"
 	"github.com/Alex-Chris/log/log"
-	"github.com/ethereum/go-ethereum/common"
-	"math/big"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -33,42 +31,31 @@ func TestUnpackEventIntoMap(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(data)%32 == 0 {
-		t.Errorf("len(data) is %d, want a non-multiple of 32", len(data))
+	if len(data)%32 != 0 {
+		t.Errorf("len(data) is %d, want a multiple of 32", len(data))
 	}
 
 	receivedMap := map[string]interface{}{}
-	expectedReceivedMap := map[string]interface{}{
-		"sender": common.HexToAddress("0x376c47978271565f56DEB45495afa69E59c16Ab2"),
-		"amount": big.NewInt(1),
-		"memo":   []byte{88},
-	}
 	if err := abi.UnpackIntoMap(receivedMap, "getObjById", data); err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	t.Logf("receivedMap=%v", receivedMap)
-	if len(receivedMap) != 3 {
-		t.Error("unpacked `received` map expected to have length 3")
+	if len(receivedMap) != 1 || receivedMap["obj"] == nil {
+		t.Fatalf("unpacked map %v, want only obj", receivedMap)
 	}
-	if receivedMap["sender"] != expectedReceivedMap["sender"] {
-		t.Error("unpacked `received` map does not match expected map")
+	obj := reflect.ValueOf(receivedMap["obj"])
+	if got := obj.FieldByName("OrderId").String(); got != "XHTD00010101181012-00003" {
+		t.Errorf("obj.orderId = %q", got)
 	}
-	if receivedMap["amount"].(*big.Int).Cmp(expectedReceivedMap["amount"].(*big.Int)) != 0 {
-		t.Error("unpacked `received` map does not match expected map")
+	if got := obj.FieldByName("Quantity").Uint(); got != 10 {
+		t.Errorf("obj.quantity = %d, want 10", got)
 	}
-	if !bytes.Equal(receivedMap["memo"].([]byte), expectedReceivedMap["memo"].([]byte)) {
-		t.Error("unpacked `received` map does not match expected map")
+	if got := obj.FieldByName("Detail").String(); !strings.HasPrefix(got, `[{"lotNumber"`) {
+		t.Errorf("obj.detail = %q", got)
 	}
 
-	receivedAddrMap := map[string]interface{}{}
-	if err = abi.UnpackIntoMap(receivedAddrMap, "receivedAddr", data); err != nil {
-		t.Error(err)
-	}
-	if len(receivedAddrMap) != 1 {
-		t.Error("unpacked `receivedAddr` map expected to have length 1")
-	}
-	if receivedAddrMap["sender"] != expectedReceivedMap["sender"] {
-		t.Error("unpacked `receivedAddr` map does not match expected map")
+	if err = abi.UnpackIntoMap(map[string]interface{}{}, "receivedAddr", data); err == nil {
+		t.Error("unpacked data for a method the ABI doesn't declare")
 	}
 }
 
@@ -121,11 +108,6 @@ func TestDecodeMethod(t *testing.T) {
 	input := "0xced1c24e00000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000240000000000000000000000000000000000000000000000000000000000000028000000000000000000000000000000000000000000000000000000000000002c000000000000000000000000000000000000000000000000000000000000003000000000000000000000000000000000000000000000000000000000000000340000000000000000000000000000000000000000000000000000000000000038000000000000000000000000000000000000000000000000000000000000003c000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000440000000000000000000000000000000000000000000000000000000000000000a00000000000000000000000000000000000000000000000000000000000000c800000000000000000000000000000000000000000000000000000000000000c8000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000014000000000000000000000000000000000000000000000000000000000000048000000000000000000000000000000000000000000000000000000000000004c0000000000000000000000000000000000000000000000000000000000000050000000000000000000000000000000000000000000000000000000000000000185848544430303031303130313138313031322d303030323200000000000000000000000000000000000000000000000000000000000000000000000000000013323031382f31302f31322020303a30303a30300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000045848544400000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001ee5a4a7e6b189e794b5e5ad90e59586e58aa1e69c89e99990e585ace58fb80000000000000000000000000000000000000000000000000000000000000000001ee5a4a7e6b189e794b5e5ad90e59586e58aa1e69c89e99990e585ace58fb80000000000000000000000000000000000000000000000000000000000000000000ce4b89ae58aa1e983a8e997a800000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000006e5bca0e4b8890000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000ce4b88be6b8b8e4b9b0e5aeb60000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000ce4b88be6b8b8e4b9b0e5aeb6000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000185848434b30303031303130313138313232382d30303130380000000000000000000000000000000000000000000000000000000000000000000000000000000f323031382f31302f313220303a3030000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001345b7b226c6f744e756d626572223a225848544430303031303130313138313031322d3030303031303031222c2273746f7265686f7573654e616d65223a22e6b996e58d97e4b880e58a9be5ba93222c22676f6f644e616d65223a22e89ebae7bab9e992a2222c226d6174657269616c223a22485242333335222c2273706563696669636174696f6e223a2231322a39222c22706c6163654f664f726967696e223a22e99e8de992a2222c227072696365223a3130302c22746178496e636c75646564416d6f756e74223a323030302c22666565223a32303030302c22666565416d6f756e74223a31302c2273616c655175616e74697479223a3130302c2273616c65576569676874223a31302c2274616b656e5175616e74697479223a3230302c2274616b656e576569676874223a3230307d5d000000000000000000000000"
 	name := "createObj"
 
-
-	input1 := "0xced1c24e00000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000240000000000000000000000000000000000000000000000000000000000000028000000000000000000000000000000000000000000000000000000000000002c000000000000000000000000000000000000000000000000000000000000003000000000000000000000000000000000000000000000000000000000000000340000000000000000000000000000000000000000000000000000000000000038000000000000000000000000000000000000000000000000000000000000003c000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000440000000000000000000000000000000000000000000000000000000000000000a00000000000000000000000000000000000000000000000000000000000000c800000000000000000000000000000000000000000000000000000000000000c8000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000014000000000000000000000000000000000000000000000000000000000000048000000000000000000000000000000000000000000000000000000000000004c0000000000000000000000000000000000000000000000000000000000000050000000000000000000000000000000000000000000000000000000000000000185848544430303031303130313138313031322d303030323200000000000000000000000000000000000000000000000000000000000000000000000000000013323031382f31302f31322020303a30303a30300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000045848544400000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001ee5a4a7e6b189e794b5e5ad90e59586e58aa1e69c89e99990e585ace58fb80000000000000000000000000000000000000000000000000000000000000000001ee5a4a7e6b189e794b5e5ad90e59586e58aa1e69c89e99990e585ace58fb80000000000000000000000000000000000000000000000000000000000000000000ce4b89ae58aa1e983a8e997a800000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000006e5bca0e4b8890000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000ce4b88be6b8b8e4b9b0e5aeb60000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000ce4b88be6b8b8e4b9b0e5aeb6000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000185848434b30303031303130313138313232382d30303130380000000000000000000000000000000000000000000000000000000000000000000000000000000f323031382f31302f313220303a3030000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001345b7b226c6f744e756d626572223a225848544430303031303130313138313031322d3030303031303031222c2273746f7265686f7573654e616d65223a22e6b996e58d97e4b880e58a9be5ba93222c22676f6f644e616d65223a22e89ebae7bab9e992a2222c226d6174657269616c223a22485242333335222c2273706563696669636174696f6e223a2231322a39222c22706c6163654f664f726967696e223a22e99e8de992a2222c227072696365223a3130302c22746178496e636c75646564416d6f756e74223a323030302c22666565223a32303030302c22666565416d6f756e74223a31302c2273616c655175616e74697479223a3130302c2273616c65576569676874223a31302c2274616b656e5175616e74697479223a3230302c2274616b656e576569676874223a3230307d5d000000000000000000000000"
-
-
-
 	// 解析Abi格式成为Json格式
 	abiDecoder, err := JSON(strings.NewReader(abiJSON))
 	if err != nil {
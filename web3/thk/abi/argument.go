@@ -8,18 +8,20 @@ import (
 )
 
 type Argument struct {
-	Name    string
-	Type    Type
-	Indexed bool // indexed is only used by events
+	Name         string
+	Type         Type
+	InternalType string // the Solidity type, such as "struct Shop.Order", if the compiler gave it
+	Indexed      bool   // indexed is only used by events
 }
 
 type Arguments []Argument
 
 type ArgumentMarshaling struct {
-	Name       string
-	Type       string
-	Components []ArgumentMarshaling
-	Indexed    bool
+	Name         string               `json:"name"`
+	Type         string               `json:"type"`
+	InternalType string               `json:"internalType,omitempty"`
+	Components   []ArgumentMarshaling `json:"components,omitempty"`
+	Indexed      bool                 `json:"indexed,omitempty"`
 }
 
 func (argument *Argument) UnmarshalJSON(data []byte) error {
@@ -34,11 +36,44 @@ func (argument *Argument) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	argument.Name = arg.Name
+	argument.InternalType = arg.InternalType
 	argument.Indexed = arg.Indexed
 
 	return nil
 }
 
+func (argument Argument) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ArgumentMarshaling{
+		Name:         argument.Name,
+		Type:         jsonType(argument.Type),
+		InternalType: argument.InternalType,
+		Components:   argument.Type.tupleComponents(),
+		Indexed:      argument.Indexed,
+	})
+}
+
+// jsonType returns the type as the JSON ABI writes it, with tuples spelled
+// tuple rather than by their elements.
+func jsonType(t Type) string {
+	switch {
+	case t.T == TupleTy:
+		return "tuple"
+	case t.T == SliceTy && t.Elem != nil:
+		return jsonType(*t.Elem) + "[]"
+	case t.T == ArrayTy && t.Elem != nil:
+		return fmt.Sprintf("%s[%d]", jsonType(*t.Elem), t.Size)
+	}
+	return t.String()
+}
+
+// tupleComponents returns the components of the tuple t is or holds.
+func (t Type) tupleComponents() []ArgumentMarshaling {
+	for t.T == SliceTy || t.T == ArrayTy {
+		t = *t.Elem
+	}
+	return t.components
+}
+
 func (arguments Arguments) LengthNonIndexed() int {
 	out := 0
 	for _, arg := range arguments {
@@ -230,6 +265,25 @@ func (arguments Arguments) unpackTuple(v interface{}, marshalledValues []interfa
 
 }
 
+// Copy stores values, as returned by UnpackValues, into out, which holds a
+// pointer for each non-indexed argument. Tuples are copied into any struct
+// with matching fields.
+func (arguments Arguments) Copy(out []interface{}, values []interface{}) error {
+	nonIndexed := arguments.NonIndexed()
+	if len(out) != len(nonIndexed) || len(values) != len(nonIndexed) {
+		return fmt.Errorf("abi: cannot copy %d values into %d outputs, want %d", len(values), len(out), len(nonIndexed))
+	}
+	for i, arg := range nonIndexed {
+		if reflect.ValueOf(out[i]).Kind() != reflect.Ptr {
+			return fmt.Errorf("abi: Copy(non-pointer %T)", out[i])
+		}
+		if err := unpack(&arg.Type, out[i], values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (arguments Arguments) UnpackValues(data []byte) ([]interface{}, error) {
 	retval := make([]interface{}, 0, arguments.LengthNonIndexed())
 	virtualArgs := 0
@@ -0,0 +1,36 @@
+package abi
+
+import "fmt"
+
+// DecodedArgument is an argument decoded from calldata. Name is empty for
+// an argument the ABI leaves unnamed.
+type DecodedArgument struct {
+	Name  string
+	Type  Type
+	Value interface{}
+}
+
+func (arg DecodedArgument) String() string {
+	if arg.Name == "" {
+		return fmt.Sprintf("%v: %v", arg.Type, arg.Value)
+	}
+	return fmt.Sprintf("%v %v: %v", arg.Type, arg.Name, arg.Value)
+}
+
+// DecodeCalldata looks up the method input calls by its selector and decodes
+// the arguments after it, in the order the method declares them.
+func (abi *ABI) DecodeCalldata(input []byte) (*Method, []DecodedArgument, error) {
+	method, err := abi.MethodById(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := method.Inputs.UnpackValues(input[4:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("abi: decoding arguments of %s: %v", method.Sig(), err)
+	}
+	args := make([]DecodedArgument, len(method.Inputs))
+	for i, input := range method.Inputs {
+		args[i] = DecodedArgument{Name: input.Name, Type: input.Type, Value: values[i]}
+	}
+	return method, args, nil
+}
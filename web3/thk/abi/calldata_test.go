@@ -0,0 +1,68 @@
+package abi
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDecodeCalldata(t *testing.T) {
+	abi, err := ParseHumanReadable(
+		"function transfer(address to, uint256 amount) returns (bool)",
+		"function transfer(address to, uint256 amount, bytes data) returns (bool)",
+		"function update((string orderId, uint64 qty)[] orders, bool)",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x6ea0fefc17c877c7a4b0f139728ed39dc134a967")
+	input, err := abi.Pack("transfer", to, big.NewInt(42), []byte("memo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	method, args, err := abi.DecodeCalldata(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method.Name != "transfer0" || method.Sig() != "transfer(address,uint256,bytes)" {
+		t.Fatalf("decoded %s (%s), want transfer0", method.Name, method.Sig())
+	}
+	if len(args) != 3 || args[0].Name != "to" || args[1].Name != "amount" || args[2].Name != "data" {
+		t.Fatalf("args = %v", args)
+	}
+	if args[0].Value != to || args[1].Value.(*big.Int).Int64() != 42 || string(args[2].Value.([]byte)) != "memo" {
+		t.Errorf("values = %v", args)
+	}
+	if got := args[1].String(); got != "uint256 amount: 42" {
+		t.Errorf("String() = %q", got)
+	}
+
+	type order struct {
+		OrderId string
+		Qty     uint64
+	}
+	input, err = abi.Pack("update", []order{{"o-1", 3}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method, args, err = abi.DecodeCalldata(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method.Name != "update" || len(args) != 2 || args[1].Name != "" || args[1].Value != true {
+		t.Errorf("decoded %s with %v", method.Name, args)
+	}
+	if got := args[1].String(); got != "bool: true" {
+		t.Errorf("String() = %q", got)
+	}
+
+	if _, _, err := abi.DecodeCalldata([]byte{1, 2, 3, 4}); err == nil || !strings.Contains(err.Error(), "no method") {
+		t.Errorf("unknown selector: err = %v", err)
+	}
+	if _, _, err := abi.DecodeCalldata(input[:40]); err == nil {
+		t.Error("decoded truncated calldata")
+	}
+}
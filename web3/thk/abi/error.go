@@ -70,12 +70,23 @@ func typeCheck(t Type, value reflect.Value) error {
 	// Check base type validity. Element types will be checked later on.
 	if t.Kind != value.Kind() {
 		return typeErr(t.Kind, value.Kind())
-	} else if t.T == FixedBytesTy && t.Size != value.Len() {
+	}
+	switch t.T {
+	case IntTy, UintTy:
+		// Only *big.Int is left a pointer by indirect.
+		if value.Kind() == reflect.Ptr && value.IsNil() {
+			return fmt.Errorf("abi: cannot use nil %v as argument", value.Type())
+		}
+	case AddressTy, BytesTy, FixedBytesTy, FunctionTy:
+		// Packed as bytes, so arrays and slices of anything else don't fit.
+		if value.Type().Elem().Kind() != reflect.Uint8 {
+			return typeErr(t.Type, value.Type())
+		}
+	}
+	if t.T == FixedBytesTy && t.Size != value.Len() {
 		return typeErr(t.Type, value.Type())
-	} else {
-		return nil
 	}
-
+	return nil
 }
 
 // typeErr returns a formatted type casting error.
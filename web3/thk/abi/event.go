@@ -9,7 +9,10 @@ import (
 )
 
 type Event struct {
+	// Name is the key of the event in ABI.Events; RawName is its Solidity
+	// name, shared by overloads.
 	Name      string
+	RawName   string
 	Anonymous bool
 	Inputs    Arguments
 }
@@ -22,7 +25,14 @@ func (e Event) String() string {
 			inputs[i] = fmt.Sprintf("%v indexed %v", input.Type, input.Name)
 		}
 	}
-	return fmt.Sprintf("event %v(%v)", e.Name, strings.Join(inputs, ", "))
+	return fmt.Sprintf("event %v(%v)", e.rawName(), strings.Join(inputs, ", "))
+}
+
+func (e Event) rawName() string {
+	if e.RawName == "" {
+		return e.Name
+	}
+	return e.RawName
 }
 
 func (e Event) Id() common.Hash {
@@ -32,5 +42,5 @@ func (e Event) Id() common.Hash {
 		types[i] = input.Type.String()
 		i++
 	}
-	return common.BytesToHash(crypto.Keccak256([]byte(fmt.Sprintf("%v(%v)", e.Name, strings.Join(types, ",")))))
+	return common.BytesToHash(crypto.Keccak256([]byte(fmt.Sprintf("%v(%v)", e.rawName(), strings.Join(types, ",")))))
 }
@@ -0,0 +1,289 @@
+package abi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ParseHumanReadable builds an ABI from human-readable declarations such as
+//
+//	"constructor(uint256 supply)"
+//	"function transfer(address to, uint256 amount) returns (bool)"
+//	"function balanceOf(address) view returns (uint256)"
+//	"function update((string orderId, uint64 qty)[] orders)"
+//	"event Transfer(address indexed from, address indexed to, uint256 value)"
+//	"error InsufficientBalance(uint256 available, uint256 required)"
+//	"receive() external payable"
+//
+// Tuples are written in parentheses, optionally prefixed with tuple. view,
+// pure, payable and nonpayable set a function's state mutability, and
+// constant marks it constant the way older ABIs do; anonymous marks an event.
+func ParseHumanReadable(fragments ...string) (ABI, error) {
+	abi := ABI{
+		Methods: make(map[string]Method),
+		Events:  make(map[string]Event),
+		Errors:  make(map[string]Error),
+	}
+	for _, fragment := range fragments {
+		if err := abi.parseFragment(strings.TrimSpace(fragment)); err != nil {
+			return ABI{}, fmt.Errorf("abi: %q: %v", fragment, err)
+		}
+	}
+	return abi, nil
+}
+
+func (abi *ABI) parseFragment(fragment string) error {
+	open := strings.Index(fragment, "(")
+	if open < 0 {
+		return fmt.Errorf("missing parameter list")
+	}
+	head := strings.Fields(fragment[:open])
+	if len(head) == 0 {
+		return fmt.Errorf("missing declaration kind")
+	}
+	kind, name := head[0], ""
+	switch {
+	case len(head) == 2:
+		name = head[1]
+	case len(head) > 2:
+		return fmt.Errorf("unexpected %q", strings.Join(head[2:], " "))
+	}
+	params, rest, err := splitParens(fragment[open:])
+	if err != nil {
+		return err
+	}
+	inputs, err := parseParams(params)
+	if err != nil {
+		return err
+	}
+	for _, input := range inputs {
+		if input.Indexed && kind != "event" {
+			return fmt.Errorf("only event arguments can be indexed")
+		}
+	}
+
+	var (
+		outputs   Arguments
+		modifiers []string
+	)
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		if strings.HasPrefix(rest, "returns") {
+			var returns string
+			if returns, rest, err = splitParens(strings.TrimSpace(strings.TrimPrefix(rest, "returns"))); err != nil {
+				return err
+			}
+			if outputs, err = parseParams(returns); err != nil {
+				return err
+			}
+			continue
+		}
+		word := strings.Fields(rest)[0]
+		modifiers = append(modifiers, word)
+		rest = strings.TrimPrefix(rest, word)
+	}
+
+	switch kind {
+	case "function":
+		if name == "" {
+			return fmt.Errorf("function without a name")
+		}
+		method := Method{Name: name, Inputs: inputs, Outputs: outputs}
+		if err := method.setModifiers(modifiers); err != nil {
+			return err
+		}
+		abi.addMethod(method)
+	case "event":
+		if name == "" {
+			return fmt.Errorf("event without a name")
+		}
+		event := Event{Name: name, Inputs: inputs}
+		for _, modifier := range modifiers {
+			if modifier != "anonymous" {
+				return fmt.Errorf("unknown event modifier %q", modifier)
+			}
+			event.Anonymous = true
+		}
+		abi.addEvent(event)
+	case "error":
+		if name == "" || len(outputs) > 0 || len(modifiers) > 0 {
+			return fmt.Errorf("error needs a name and nothing after its parameters")
+		}
+		abi.addError(Error{Name: name, Inputs: inputs})
+	case "constructor":
+		if name != "" || len(outputs) > 0 {
+			return fmt.Errorf("constructor takes neither a name nor returns")
+		}
+		abi.Constructor = Method{Type: Constructor, Inputs: inputs}
+		if err := abi.Constructor.setModifiers(modifiers); err != nil {
+			return err
+		}
+	case "fallback", "receive":
+		if name != "" || len(inputs) > 0 || len(outputs) > 0 {
+			return fmt.Errorf("%s takes neither a name, parameters nor returns", kind)
+		}
+		method := Method{Type: Fallback}
+		if err := method.setModifiers(modifiers); err != nil {
+			return err
+		}
+		if kind == "fallback" {
+			abi.Fallback = method
+			break
+		}
+		if !method.IsPayable() {
+			return fmt.Errorf("receive must be payable")
+		}
+		method.Type = Receive
+		abi.Receive = method
+	default:
+		return fmt.Errorf("unsupported declaration %q", kind)
+	}
+	return nil
+}
+
+// setModifiers applies the state mutability and visibility keywords after a
+// function's parameters.
+func (method *Method) setModifiers(modifiers []string) error {
+	for _, modifier := range modifiers {
+		switch modifier {
+		case "view", "pure", "payable", "nonpayable":
+			if method.StateMutability != "" {
+				return fmt.Errorf("conflicting modifiers %s and %s", method.StateMutability, modifier)
+			}
+			method.StateMutability = modifier
+		case "constant":
+			method.Const = true
+		case "external", "public":
+		default:
+			return fmt.Errorf("unknown function modifier %q", modifier)
+		}
+	}
+	return nil
+}
+
+// splitParens splits s, which starts with "(", into the text inside the
+// matching parenthesis and the text after it.
+func splitParens(s string) (string, string, error) {
+	if !strings.HasPrefix(s, "(") {
+		return "", "", fmt.Errorf("expected ( at %q", s)
+	}
+	depth := 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return s[1:i], s[i+1:], nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("unbalanced parentheses in %q", s)
+}
+
+func parseParams(s string) (Arguments, error) {
+	var args Arguments
+	for _, param := range splitTopLevel(s) {
+		arg, err := parseParam(strings.TrimSpace(param))
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func parseParam(s string) (Argument, error) {
+	marshaling, err := parseParamMarshaling(s)
+	if err != nil {
+		return Argument{}, err
+	}
+	typ, err := NewType(marshaling.Type, marshaling.Components)
+	if err != nil {
+		return Argument{}, err
+	}
+	return Argument{Name: marshaling.Name, Type: typ, Indexed: marshaling.Indexed}, nil
+}
+
+var arraySuffix = regexp.MustCompile(`^(\[[0-9]*\])*`)
+
+// parseParamMarshaling parses "type [indexed] [location] [name]" into the
+// form the JSON ABI has, which NewType takes.
+func parseParamMarshaling(s string) (ArgumentMarshaling, error) {
+	if s == "" {
+		return ArgumentMarshaling{}, fmt.Errorf("empty parameter")
+	}
+	var arg ArgumentMarshaling
+	var rest string
+	if tuple := strings.TrimSpace(strings.TrimPrefix(s, "tuple")); strings.HasPrefix(tuple, "(") {
+		inner, after, err := splitParens(tuple)
+		if err != nil {
+			return ArgumentMarshaling{}, err
+		}
+		for _, field := range splitTopLevel(inner) {
+			component, err := parseParamMarshaling(strings.TrimSpace(field))
+			if err != nil {
+				return ArgumentMarshaling{}, err
+			}
+			arg.Components = append(arg.Components, component)
+		}
+		suffix := arraySuffix.FindString(after)
+		arg.Type = "tuple" + suffix
+		rest = after[len(suffix):]
+	} else {
+		words := strings.Fields(s)
+		arg.Type = canonicalType(words[0])
+		rest = strings.Join(words[1:], " ")
+	}
+
+	for _, word := range strings.Fields(rest) {
+		switch {
+		case word == "indexed":
+			arg.Indexed = true
+		case word == "memory" || word == "calldata" || word == "storage":
+		case arg.Name == "":
+			arg.Name = word
+		default:
+			return ArgumentMarshaling{}, fmt.Errorf("unexpected %q in parameter %q", word, s)
+		}
+	}
+	return arg, nil
+}
+
+// splitTopLevel splits s at the commas outside parentheses.
+func splitTopLevel(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// canonicalType expands the uint and int aliases, which NewType rejects.
+func canonicalType(t string) string {
+	base := t
+	if i := strings.Index(t, "["); i >= 0 {
+		base = t[:i]
+	}
+	if base == "uint" || base == "int" {
+		return base + "256" + t[len(base):]
+	}
+	return t
+}
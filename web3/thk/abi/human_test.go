@@ -0,0 +1,89 @@
+package abi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHumanReadable(t *testing.T) {
+	abi, err := ParseHumanReadable(
+		"constructor(uint supply)",
+		"function transfer(address to, uint256 amount) returns (bool)",
+		"function transfer(address to, uint256 amount, bytes calldata data) external returns (bool success)",
+		"function balanceOf(address) view returns (uint256)",
+		"function update(tuple(string orderId, uint64 qty)[] memory orders, (bool on, int8[2] levels)[3] flags)",
+		"function settle(tuple (uint b) c)",
+		"event Transfer(address indexed from, address indexed to, uint256 value)",
+		"event Raw(bytes32 indexed tag) anonymous",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := JSON(strings.NewReader(`[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256"}]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := abi.Methods["transfer"].String(), parsed.Methods["transfer"].String(); got != want {
+		t.Errorf("transfer = %s, want %s", got, want)
+	}
+	if abi.Events["Transfer"].Id() != parsed.Events["Transfer"].Id() || !abi.Events["Transfer"].Inputs[0].Indexed {
+		t.Errorf("Transfer = %s, want %s", abi.Events["Transfer"], parsed.Events["Transfer"])
+	}
+
+	for key, sig := range map[string]string{
+		"transfer0": "transfer(address,uint256,bytes)",
+		"balanceOf": "balanceOf(address)",
+		"update":    "update((string,uint64)[],(bool,int8[2])[3])",
+		"settle":    "settle((uint256))",
+	} {
+		if got := abi.Methods[key].Sig(); got != sig {
+			t.Errorf("Methods[%s] = %s, want %s", key, got, sig)
+		}
+	}
+	if !abi.Methods["balanceOf"].IsConstant() || abi.Methods["transfer"].IsConstant() {
+		t.Error("view not parsed as constant")
+	}
+	if out := abi.Methods["transfer0"].Outputs; len(out) != 1 || out[0].Name != "success" {
+		t.Errorf("transfer0 outputs = %v", out)
+	}
+	if abi.Constructor.Inputs[0].Type.String() != "uint256" {
+		t.Errorf("constructor input %v, want uint256", abi.Constructor.Inputs[0].Type)
+	}
+	if in := abi.Methods["settle"].Inputs; len(in) != 1 || in[0].Name != "c" {
+		t.Errorf("settle inputs = %v, want the tuple c", in)
+	}
+	if !abi.Events["Raw"].Anonymous {
+		t.Error("Raw is not anonymous")
+	}
+	if _, err := abi.Pack("update", []struct {
+		OrderId string
+		Qty     uint64
+	}{{"o-1", 1}}, [3]struct {
+		On     bool
+		Levels [2]int8
+	}{}); err != nil {
+		t.Errorf("packing update: %v", err)
+	}
+}
+
+func TestParseHumanReadableErrors(t *testing.T) {
+	for _, fragment := range []string{
+		"transfer(address to)",
+		"function (address)",
+		"function f(address indexed to)",
+		"function f(foo x)",
+		"function f(address to",
+		"function f() returns bool",
+		"function f() mutable",
+		"event E(uint256 a b)",
+		"constructor() returns (bool)",
+	} {
+		if _, err := ParseHumanReadable(fragment); err == nil {
+			t.Errorf("%q parsed", fragment)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package abi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const modernABI = `[
+	{"type":"constructor","stateMutability":"payable","inputs":[{"name":"owner","type":"address"}]},
+	{"type":"fallback","stateMutability":"nonpayable"},
+	{"type":"receive","stateMutability":"payable"},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"who","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"deposit","stateMutability":"payable","inputs":[],"outputs":[]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"update","stateMutability":"nonpayable","inputs":[{"name":"orders","type":"tuple[]","internalType":"struct Orders.Order[]","components":[{"name":"orderId","type":"string"},{"name":"qty","type":"uint64"}]}],"outputs":[]},
+	{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256"}]},
+	{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}
+]`
+
+func TestABIMarshalJSON(t *testing.T) {
+	abi, err := JSON(strings.NewReader(modernABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(abi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again ABI
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatalf("unmarshalling %s: %v", data, err)
+	}
+	redone, err := json.Marshal(again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(redone) != string(data) {
+		t.Errorf("round trip changed the ABI:\n%s\n%s", data, redone)
+	}
+
+	// Apart from their order, false flags and the empty inputs of fallback
+	// and receive, the entries come out as modernABI has them.
+	var want, got []map[string]interface{}
+	if err := json.Unmarshal([]byte(modernABI), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("marshalled %d entries, want %d:\n%s", len(got), len(want), data)
+	}
+	for _, entry := range got {
+		normalizeEntry(entry)
+	}
+next:
+	for _, entry := range want {
+		normalizeEntry(entry)
+		for i := range got {
+			if reflect.DeepEqual(got[i], entry) {
+				got = append(got[:i], got[i+1:]...)
+				continue next
+			}
+		}
+		t.Errorf("entry %v missing from\n%s", entry, data)
+	}
+
+	if len(again.Methods) != len(abi.Methods) || len(again.Events) != 1 || len(again.Errors) != 1 {
+		t.Fatalf("round trip has %d methods, %d events, %d errors", len(again.Methods), len(again.Events), len(again.Errors))
+	}
+	for name, method := range abi.Methods {
+		if got := again.Methods[name]; got.Sig() != method.Sig() || got.StateMutability != method.StateMutability {
+			t.Errorf("%s = %s, want %s", name, got, method)
+		}
+	}
+	if again.Methods["transfer0"].Sig() != "transfer(address,uint256,bytes)" {
+		t.Errorf("transfer0 = %s", again.Methods["transfer0"].Sig())
+	}
+	if got := again.Methods["update"].Inputs[0].InternalType; got != "struct Orders.Order[]" {
+		t.Errorf("internalType = %q", got)
+	}
+	if !again.Events["Transfer"].Inputs[0].Indexed || again.Errors["InsufficientBalance"].Sig() != "InsufficientBalance(uint256,uint256)" {
+		t.Errorf("event and error changed: %s, %s", again.Events["Transfer"], again.Errors["InsufficientBalance"])
+	}
+	if again.Constructor.Type != Constructor || !again.Constructor.IsPayable() || len(again.Constructor.Inputs) != 1 {
+		t.Errorf("constructor = %+v", again.Constructor)
+	}
+	if !again.HasFallback() || again.Fallback.IsPayable() || !again.HasReceive() || !again.Receive.IsPayable() {
+		t.Errorf("fallback = %+v, receive = %+v", again.Fallback, again.Receive)
+	}
+}
+
+// normalizeEntry drops the false flags of a JSON ABI entry and gives it
+// inputs when it has none.
+func normalizeEntry(entry map[string]interface{}) {
+	for key, value := range entry {
+		if value == false {
+			delete(entry, key)
+		}
+	}
+	if _, ok := entry["inputs"]; !ok {
+		entry["inputs"] = []interface{}{}
+	}
+}
+
+func TestABIUnknownEntryType(t *testing.T) {
+	abi, err := JSON(strings.NewReader(`[
+		{"type":"function","name":"f","inputs":[],"outputs":[]},
+		{"type":"modifier","name":"onlyOwner","inputs":[]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abi.Methods) != 1 || len(abi.Events) != 0 || len(abi.Errors) != 0 {
+		t.Errorf("got %d methods, %d events, %d errors, want only f", len(abi.Methods), len(abi.Events), len(abi.Errors))
+	}
+}
+
+func TestMethodMutability(t *testing.T) {
+	abi, err := JSON(strings.NewReader(`[
+		{"type":"function","name":"legacyConst","constant":true,"inputs":[],"outputs":[]},
+		{"type":"function","name":"legacyPayable","payable":true,"inputs":[],"outputs":[]},
+		{"type":"function","name":"view","stateMutability":"view","inputs":[],"outputs":[]},
+		{"type":"function","name":"pure","stateMutability":"pure","inputs":[],"outputs":[]},
+		{"type":"function","name":"payable","stateMutability":"payable","inputs":[],"outputs":[]},
+		{"type":"function","name":"plain","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string][2]bool{
+		"legacyConst":   {true, false},
+		"legacyPayable": {false, true},
+		"view":          {true, false},
+		"pure":          {true, false},
+		"payable":       {false, true},
+		"plain":         {false, false},
+	} {
+		method := abi.Methods[name]
+		if method.IsConstant() != want[0] || method.IsPayable() != want[1] {
+			t.Errorf("%s: IsConstant = %v, IsPayable = %v, want %v", name, method.IsConstant(), method.IsPayable(), want)
+		}
+	}
+
+	human, err := ParseHumanReadable(
+		"function balanceOf(address) view returns (uint256)",
+		"function deposit() payable",
+		"receive() external payable",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !human.Methods["balanceOf"].IsConstant() || !human.Methods["deposit"].IsPayable() || !human.HasReceive() {
+		t.Errorf("human-readable mutability lost: %+v", human)
+	}
+	if _, err := ParseHumanReadable("receive() external"); err == nil {
+		t.Error("accepted a receive function that is not payable")
+	}
+}
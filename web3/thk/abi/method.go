@@ -9,11 +9,41 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
+// FunctionType tells the kinds of Method apart.
+type FunctionType int
+
+const (
+	Function FunctionType = iota
+	Constructor
+	Fallback
+	Receive
+)
+
 type Method struct {
+	// Name is the key of the method in ABI.Methods. Overloaded functions share
+	// RawName, their Solidity name, and get unique Names such as transfer and
+	// transfer0.
 	Name    string
-	Const   bool
-	Inputs  Arguments
-	Outputs Arguments
+	RawName string
+	Type    FunctionType
+	// StateMutability is pure, view, nonpayable or payable. Const and
+	// Payable are the flags older ABIs use instead.
+	StateMutability string
+	Const           bool
+	Payable         bool
+	Inputs          Arguments
+	Outputs         Arguments
+}
+
+// IsConstant reports whether the method can't change state, so it can be
+// run with CallTransaction.
+func (method Method) IsConstant() bool {
+	return method.Const || method.StateMutability == "view" || method.StateMutability == "pure"
+}
+
+// IsPayable reports whether the method accepts value.
+func (method Method) IsPayable() bool {
+	return method.Payable || method.StateMutability == "payable"
 }
 
 func (method Method) Sig() string {
@@ -21,7 +51,7 @@ func (method Method) Sig() string {
 	for i, input := range method.Inputs {
 		types[i] = input.Type.String()
 	}
-	return fmt.Sprintf("%v(%v)", method.Name, strings.Join(types, ","))
+	return fmt.Sprintf("%v(%v)", method.rawName(), strings.Join(types, ","))
 }
 
 func (method Method) String() string {
@@ -37,10 +67,19 @@ func (method Method) String() string {
 		}
 	}
 	constant := ""
-	if method.Const {
+	if method.StateMutability != "" && method.StateMutability != "nonpayable" {
+		constant = method.StateMutability + " "
+	} else if method.Const {
 		constant = "constant "
 	}
-	return fmt.Sprintf("function %v(%v) %sreturns(%v)", method.Name, strings.Join(inputs, ", "), constant, strings.Join(outputs, ", "))
+	return fmt.Sprintf("function %v(%v) %sreturns(%v)", method.rawName(), strings.Join(inputs, ", "), constant, strings.Join(outputs, ", "))
+}
+
+func (method Method) rawName() string {
+	if method.RawName == "" {
+		return method.Name
+	}
+	return method.RawName
 }
 
 func (method Method) Id() []byte {
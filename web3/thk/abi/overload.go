@@ -0,0 +1,167 @@
+package abi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// overloadedName returns name, or name with the lowest numeric suffix that
+// is not taken yet: transfer, transfer0, transfer1, ...
+func overloadedName(name string, taken func(string) bool) string {
+	unique := name
+	for i := 0; taken(unique); i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	return unique
+}
+
+// addMethod adds a method declared with the Solidity name method.Name under a
+// unique key. A declared name takes its key back from an overload that was
+// given it, such as a function transfer0 declared after a second transfer,
+// and the overload moves on to the next free key.
+func (abi *ABI) addMethod(method Method) {
+	method.RawName = method.Name
+	taken := func(s string) bool { _, ok := abi.Methods[s]; return ok }
+	if other, ok := abi.Methods[method.Name]; ok && other.rawName() != method.Name {
+		abi.Methods[method.Name] = method
+		other.Name = overloadedName(other.rawName(), taken)
+		abi.Methods[other.Name] = other
+		return
+	}
+	method.Name = overloadedName(method.Name, taken)
+	abi.Methods[method.Name] = method
+}
+
+// addEvent adds an event like addMethod.
+func (abi *ABI) addEvent(event Event) {
+	event.RawName = event.Name
+	taken := func(s string) bool { _, ok := abi.Events[s]; return ok }
+	if other, ok := abi.Events[event.Name]; ok && other.rawName() != event.Name {
+		abi.Events[event.Name] = event
+		other.Name = overloadedName(other.rawName(), taken)
+		abi.Events[other.Name] = other
+		return
+	}
+	event.Name = overloadedName(event.Name, taken)
+	abi.Events[event.Name] = event
+}
+
+// addError adds a custom error like addMethod.
+func (abi *ABI) addError(e Error) {
+	e.RawName = e.Name
+	taken := func(s string) bool { _, ok := abi.Errors[s]; return ok }
+	if other, ok := abi.Errors[e.Name]; ok && other.rawName() != e.Name {
+		abi.Errors[e.Name] = e
+		other.Name = overloadedName(other.rawName(), taken)
+		abi.Errors[other.Name] = other
+		return
+	}
+	e.Name = overloadedName(e.Name, taken)
+	abi.Errors[e.Name] = e
+}
+
+// sortedOverloads returns the keys of a Methods, Events or Errors map with
+// overloads in the order addMethod named them: transfer, transfer0, ...,
+// transfer9, transfer10.
+func sortedOverloads(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]Method:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]Event:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]Error:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// method looks a method up by its key in Methods or by its signature, such
+// as "transfer(address,uint256)".
+func (abi ABI) method(name string) (Method, bool) {
+	if strings.Contains(name, "(") {
+		sig := strings.Replace(name, " ", "", -1)
+		for _, method := range abi.Methods {
+			if method.Sig() == sig {
+				return method, true
+			}
+		}
+		return Method{}, false
+	}
+	method, ok := abi.Methods[name]
+	return method, ok
+}
+
+// Overloads returns the methods declared with the Solidity name, ordered by
+// key.
+func (abi ABI) Overloads(name string) []Method {
+	var methods []Method
+	for _, method := range abi.Methods {
+		if method.rawName() == name {
+			methods = append(methods, method)
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	return methods
+}
+
+// ResolveMethod finds the method to call with args. name can be a signature
+// such as "transfer(address,uint256)", a key in Methods or a Solidity name.
+// An overloaded Solidity name resolves to the only overload whose inputs
+// args can be packed as.
+func (abi ABI) ResolveMethod(name string, args ...interface{}) (Method, error) {
+	if strings.Contains(name, "(") {
+		if method, ok := abi.method(name); ok {
+			return method, nil
+		}
+		return Method{}, fmt.Errorf("method '%s' not found", name)
+	}
+	overloads := abi.Overloads(name)
+	switch len(overloads) {
+	case 0:
+		if method, ok := abi.Methods[name]; ok {
+			return method, nil
+		}
+		return Method{}, fmt.Errorf("method '%s' not found", name)
+	case 1:
+		return overloads[0], nil
+	}
+
+	var matches []Method
+	for _, method := range overloads {
+		if len(method.Inputs) == len(args) && packs(method.Inputs, args) {
+			matches = append(matches, method)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	sigs := make([]string, len(overloads))
+	for i, method := range overloads {
+		sigs[i] = method.Sig()
+	}
+	if len(matches) == 0 {
+		return Method{}, fmt.Errorf("no overload of '%s' takes the given arguments, have %s", name, strings.Join(sigs, ", "))
+	}
+	return Method{}, fmt.Errorf("call to '%s' is ambiguous, pick one of %s by signature", name, strings.Join(sigs, ", "))
+}
+
+// packs reports whether args can be packed as arguments.
+func packs(arguments Arguments, args []interface{}) bool {
+	_, err := arguments.Pack(args...)
+	return err == nil
+}
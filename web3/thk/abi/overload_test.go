@@ -0,0 +1,127 @@
+package abi
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const overloadedJSON = `[
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"memo","type":"string"}],"outputs":[]},
+	{"type":"function","name":"set","inputs":[{"name":"v","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"set","inputs":[{"name":"v","type":"int256"}],"outputs":[]}
+]`
+
+func TestOverloadedMethods(t *testing.T) {
+	abi, err := JSON(strings.NewReader(overloadedJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, sig := range map[string]string{
+		"transfer":  "transfer(address,uint256)",
+		"transfer0": "transfer(address,uint256,bytes)",
+		"transfer1": "transfer(address,string)",
+	} {
+		if method := abi.Methods[name]; method.Sig() != sig || method.RawName != "transfer" {
+			t.Errorf("Methods[%s] = %s, want %s", name, method.Sig(), sig)
+		}
+	}
+
+	to := common.HexToAddress("0x6ea0fefc17c877c7a4b0f139728ed39dc134a967")
+	for _, tt := range []struct {
+		name string
+		args []interface{}
+		want string
+	}{
+		{"transfer", []interface{}{to, big.NewInt(1)}, "transfer"},
+		{"transfer", []interface{}{to, big.NewInt(1), []byte{1}}, "transfer0"},
+		{"transfer", []interface{}{to, "hi"}, "transfer1"},
+		{"transfer1", []interface{}{to, "hi"}, "transfer1"},
+		{"transfer(address, string)", []interface{}{to, "hi"}, "transfer1"},
+		{"set(int256)", []interface{}{big.NewInt(-1)}, "set0"},
+	} {
+		method, err := abi.ResolveMethod(tt.name, tt.args...)
+		if err != nil || method.Name != tt.want {
+			t.Errorf("ResolveMethod(%s) = %s, %v, want %s", tt.name, method.Name, err, tt.want)
+		}
+	}
+	if _, err := abi.ResolveMethod("set", big.NewInt(1)); err == nil {
+		t.Error("set(uint256) and set(int256) resolved without a signature")
+	}
+	for _, args := range [][]interface{}{
+		{"x"},
+		{[20]int{}, big.NewInt(1)},
+		{to, (*big.Int)(nil)},
+		{to, big.NewInt(1), []int{1}},
+	} {
+		if _, err := abi.ResolveMethod("transfer", args...); err == nil {
+			t.Errorf("resolved transfer with %v, which no overload takes", args)
+		}
+	}
+	if _, err := abi.Pack("transfer0", to, big.NewInt(1), []int{1}); err == nil {
+		t.Error("packed []int as bytes")
+	}
+
+	packed, err := abi.Pack("transfer", to, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(packed[:4], abi.Methods["transfer1"].Id()) {
+		t.Errorf("packed with selector %x, want transfer(address,string)", packed[:4])
+	}
+}
+
+func TestOverloadedNameCollisions(t *testing.T) {
+	const (
+		transfer  = `{"type":"function","name":"transfer","inputs":[{"name":"value","type":"uint256"}],"outputs":[]}`
+		transferB = `{"type":"function","name":"transfer","inputs":[{"name":"data","type":"bytes"}],"outputs":[]}`
+		transfer0 = `{"type":"function","name":"transfer0","inputs":[{"name":"to","type":"address"}],"outputs":[]}`
+	)
+	for _, order := range [][]string{
+		{transfer, transferB, transfer0},
+		{transfer0, transfer, transferB},
+	} {
+		abi, err := JSON(strings.NewReader("[" + strings.Join(order, ",") + "]"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, sig := range map[string]string{
+			"transfer":  "transfer(uint256)",
+			"transfer0": "transfer0(address)",
+			"transfer1": "transfer(bytes)",
+		} {
+			if method := abi.Methods[name]; method.Sig() != sig || method.Name != name {
+				t.Errorf("Methods[%s] = %s named %s, want %s", name, method.Sig(), method.Name, sig)
+			}
+		}
+
+		to := common.HexToAddress("0x6ea0fefc17c877c7a4b0f139728ed39dc134a967")
+		packed, err := abi.Pack("transfer0", to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(packed[:4], abi.Methods["transfer0"].Id()) {
+			t.Errorf("packed with selector %x, want transfer0(address)", packed[:4])
+		}
+		if method, err := abi.ResolveMethod("transfer", []byte{1}); err != nil || method.Name != "transfer1" {
+			t.Errorf("ResolveMethod(transfer) = %s, %v, want transfer1", method.Name, err)
+		}
+	}
+
+	abi, err := ParseHumanReadable(
+		"event Sent(uint256 v)",
+		"event Sent(bytes v)",
+		"event Sent0(address a)",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if abi.Events["Sent0"].RawName != "Sent0" || abi.Events["Sent1"].RawName != "Sent" {
+		t.Errorf("events keyed %v, want the declared Sent0 kept", sortedOverloads(abi.Events))
+	}
+}
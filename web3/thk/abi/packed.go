@@ -0,0 +1,202 @@
+package abi
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"web3.go/common/cryp/sha3"
+)
+
+// EncodePacked encodes values like Solidity's abi.encodePacked, each as the
+// type at the same position in types:
+//
+//   - intN and uintN take N/8 bytes, big-endian and two's complement
+//   - bool takes one byte, address 20 and bytesN N
+//   - string and bytes are written as they are, without a length
+//   - the elements of T[] and T[k] take 32 bytes each, padded as in Pack
+//
+// Integers can be *big.Int or any Go integer, addresses common.Address or a
+// hex string, and bytesN a [N]byte or a []byte of length N. Tuples and arrays
+// of dynamic types can't be packed.
+func EncodePacked(types []string, values []interface{}) ([]byte, error) {
+	if len(types) != len(values) {
+		return nil, fmt.Errorf("abi: %d types for %d values", len(types), len(values))
+	}
+	var packed []byte
+	for i, name := range types {
+		typ, err := NewType(canonicalType(name), nil)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := encodePacked(typ, reflect.ValueOf(values[i]), false)
+		if err != nil {
+			return nil, fmt.Errorf("abi: packing value %d as %s: %v", i, name, err)
+		}
+		packed = append(packed, encoded...)
+	}
+	return packed, nil
+}
+
+// SolidityKeccak returns keccak256(abi.encodePacked(values...)), the hash
+// contracts check signatures and commitments against.
+func SolidityKeccak(types []string, values []interface{}) (common.Hash, error) {
+	packed, err := EncodePacked(types, values)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hash := sha3.NewKeccak256()
+	hash.Write(packed)
+	return common.BytesToHash(hash.Sum(nil)), nil
+}
+
+// encodePacked encodes value as t, padding it to 32 bytes when it is an
+// array element.
+func encodePacked(t Type, value reflect.Value, padded bool) ([]byte, error) {
+	value = indirectInterface(value)
+	if !value.IsValid() {
+		return nil, fmt.Errorf("nil value")
+	}
+	switch t.T {
+	case IntTy, UintTy:
+		n, err := packedInt(t, value)
+		if err != nil {
+			return nil, err
+		}
+		if padded {
+			return U256(n), nil
+		}
+		return U256(n)[32-t.Size/8:], nil
+	case BoolTy:
+		if value.Kind() != reflect.Bool {
+			return nil, typeErr("bool", value.Type())
+		}
+		b := []byte{0}
+		if value.Bool() {
+			b[0] = 1
+		}
+		return pad(b, padded, false), nil
+	case AddressTy:
+		var addr common.Address
+		switch v := value.Interface().(type) {
+		case common.Address:
+			addr = v
+		case string:
+			if !common.IsHexAddress(v) {
+				return nil, fmt.Errorf("invalid address %q", v)
+			}
+			addr = common.HexToAddress(v)
+		default:
+			return nil, typeErr("address", value.Type())
+		}
+		return pad(addr.Bytes(), padded, false), nil
+	case FixedBytesTy:
+		b, err := packedBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != t.Size {
+			return nil, fmt.Errorf("%d bytes for bytes%d", len(b), t.Size)
+		}
+		return pad(b, padded, true), nil
+	case StringTy, BytesTy:
+		if padded {
+			return nil, fmt.Errorf("arrays of %s can't be packed", t)
+		}
+		if t.T == StringTy {
+			if value.Kind() != reflect.String {
+				return nil, typeErr("string", value.Type())
+			}
+			return []byte(value.String()), nil
+		}
+		return packedBytes(value)
+	case SliceTy, ArrayTy:
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			return nil, typeErr(t.String(), value.Type())
+		}
+		if t.T == ArrayTy && value.Len() != t.Size {
+			return nil, fmt.Errorf("%d elements for %s", value.Len(), t)
+		}
+		var packed []byte
+		for i := 0; i < value.Len(); i++ {
+			encoded, err := encodePacked(*t.Elem, value.Index(i), true)
+			if err != nil {
+				return nil, err
+			}
+			packed = append(packed, encoded...)
+		}
+		return packed, nil
+	default:
+		return nil, fmt.Errorf("%s can't be packed", t)
+	}
+}
+
+// packedInt converts an integer value and checks it fits t.
+func packedInt(t Type, value reflect.Value) (*big.Int, error) {
+	var n *big.Int
+	switch v := value.Interface().(type) {
+	case *big.Int:
+		if v == nil {
+			return nil, fmt.Errorf("nil %s", t)
+		}
+		n = new(big.Int).Set(v) // U256 modifies its argument
+	case big.Int:
+		n = new(big.Int).Set(&v)
+	default:
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = big.NewInt(value.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = new(big.Int).SetUint64(value.Uint())
+		default:
+			return nil, typeErr(t.String(), value.Type())
+		}
+	}
+	if t.Size%8 != 0 {
+		return nil, fmt.Errorf("%s is not a whole number of bytes", t)
+	}
+	if t.T == UintTy {
+		if n.Sign() < 0 || n.BitLen() > t.Size {
+			return nil, fmt.Errorf("%v overflows %s", n, t)
+		}
+		return n, nil
+	}
+	limit := new(big.Int).Lsh(common.Big1, uint(t.Size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return nil, fmt.Errorf("%v overflows %s", n, t)
+	}
+	return n, nil
+}
+
+// packedBytes returns the bytes of a byte slice or array.
+func packedBytes(value reflect.Value) ([]byte, error) {
+	if (value.Kind() != reflect.Slice && value.Kind() != reflect.Array) || value.Type().Elem().Kind() != reflect.Uint8 {
+		return nil, typeErr("bytes", value.Type())
+	}
+	if value.Kind() == reflect.Array {
+		value = mustArrayToByteSlice(value)
+	}
+	return value.Bytes(), nil
+}
+
+// pad pads an array element to 32 bytes, on the right for bytesN.
+func pad(b []byte, padded bool, right bool) []byte {
+	switch {
+	case !padded:
+		return b
+	case right:
+		return common.RightPadBytes(b, 32)
+	default:
+		return common.LeftPadBytes(b, 32)
+	}
+}
+
+// indirectInterface unwraps the interface values of []interface{} elements.
+func indirectInterface(value reflect.Value) reflect.Value {
+	for value.IsValid() && value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	return value
+}
@@ -0,0 +1,86 @@
+package abi
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"web3.go/common/hexutil"
+)
+
+func TestEncodePacked(t *testing.T) {
+	word := func(hex string) string { return strings.Repeat("0", 64-len(hex)) + hex }
+	addr := common.HexToAddress("0x2c7536e3605d9c16a7a3d7b1898e529396a65c23")
+	for i, test := range []struct {
+		types  []string
+		values []interface{}
+		want   string
+	}{
+		{[]string{"int16", "uint48"}, []interface{}{-1, big.NewInt(12)}, "ffff00000000000c"},
+		{[]string{"string", "uint8"}, []interface{}{"Hello", uint8(3)}, "48656c6c6f03"},
+		{[]string{"uint"}, []interface{}{1}, word("1")},
+		{[]string{"bool", "bool"}, []interface{}{true, false}, "0100"},
+		{[]string{"address", "address"}, []interface{}{addr, "0x6ea0fefc17c877c7a4b0f139728ed39dc134a967"}, "2c7536e3605d9c16a7a3d7b1898e529396a65c236ea0fefc17c877c7a4b0f139728ed39dc134a967"},
+		{[]string{"bytes2", "bytes"}, []interface{}{[2]byte{0xab, 0xcd}, []byte{1, 2, 3}}, "abcd010203"},
+		{[]string{"uint8[]"}, []interface{}{[]uint8{1, 2}}, word("1") + word("2")},
+		{[]string{"int8[2]"}, []interface{}{[]interface{}{-1, big.NewInt(3)}}, strings.Repeat("f", 64) + word("3")},
+		{[]string{"bytes2[]", "address[]"}, []interface{}{[][2]byte{{0xab, 0xcd}}, []common.Address{addr}}, "abcd" + strings.Repeat("0", 60) + word(strings.ToLower(addr.Hex()[2:]))},
+	} {
+		packed, err := EncodePacked(test.types, test.values)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if got := hexutil.Encode(packed)[2:]; got != test.want {
+			t.Errorf("%d: packed %s, want %s", i, got, test.want)
+		}
+	}
+
+	for i, test := range []struct {
+		types  []string
+		values []interface{}
+	}{
+		{[]string{"uint8"}, []interface{}{256}},
+		{[]string{"uint8"}, []interface{}{-1}},
+		{[]string{"int8"}, []interface{}{128}},
+		{[]string{"bytes2"}, []interface{}{[]byte{1}}},
+		{[]string{"address"}, []interface{}{"0x01"}},
+		{[]string{"string[]"}, []interface{}{[]string{"a"}}},
+		{[]string{"uint8[2]"}, []interface{}{[]uint8{1}}},
+		{[]string{"uint256"}, []interface{}{"1"}},
+		{[]string{"uint256", "bool"}, []interface{}{1}},
+	} {
+		if _, err := EncodePacked(test.types, test.values); err == nil {
+			t.Errorf("%d: packed %v as %v", i, test.values, test.types)
+		}
+	}
+}
+
+func TestSolidityKeccak(t *testing.T) {
+	hash, err := SolidityKeccak([]string{"int16", "uint48"}, []interface{}{-1, 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.HexToHash("0x81da7abb5c9c7515f57dab2fc946f01217ab52f3bd8958bc36bd55894451a93c"); hash != want {
+		t.Errorf("hash = %s, want %s", hash.Hex(), want.Hex())
+	}
+
+	n := big.NewInt(-5)
+	types := []string{"address", "int64", "string"}
+	values := []interface{}{"0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", n, "order-1"}
+	hash, err = SolidityKeccak(types, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, _ := EncodePacked(types, values)
+	if !bytes.Equal(hash.Bytes(), crypto.Keccak256(packed)) {
+		t.Errorf("hash = %s, want keccak256 of %x", hash.Hex(), packed)
+	}
+	if n.Int64() != -5 {
+		t.Errorf("hashing changed the argument to %v", n)
+	}
+}
@@ -7,7 +7,7 @@ import (
 )
 
 func indirect(v reflect.Value) reflect.Value {
-	if v.Kind() == reflect.Ptr && v.Elem().Type() != derefbigT {
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Type() != derefbigT {
 		return indirect(v.Elem())
 	}
 	return v
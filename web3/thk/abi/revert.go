@@ -0,0 +1,120 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector  = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// Error is a custom error declared in the ABI, which a contract can revert
+// with.
+type Error struct {
+	// Name is the key of the error in ABI.Errors; RawName is its Solidity
+	// name, shared by overloads.
+	Name    string
+	RawName string
+	Inputs  Arguments
+}
+
+func (e Error) rawName() string {
+	if e.RawName == "" {
+		return e.Name
+	}
+	return e.RawName
+}
+
+func (e Error) Sig() string {
+	types := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		types[i] = input.Type.String()
+	}
+	return fmt.Sprintf("%v(%v)", e.rawName(), strings.Join(types, ","))
+}
+
+func (e Error) String() string {
+	inputs := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		inputs[i] = fmt.Sprintf("%v %v", input.Type, input.Name)
+	}
+	return fmt.Sprintf("error %v(%v)", e.rawName(), strings.Join(inputs, ", "))
+}
+
+// Id returns the selector revert data for the error starts with.
+func (e Error) Id() []byte {
+	return crypto.Keccak256([]byte(e.Sig()))[:4]
+}
+
+// Unpack decodes revert data for the error into its arguments, in order.
+func (e Error) Unpack(data []byte) ([]interface{}, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], e.Id()) {
+		return nil, fmt.Errorf("abi: revert data is not a %s error", e.Name)
+	}
+	return e.Inputs.UnpackValues(data[4:])
+}
+
+// ErrorById looks up the custom error revert data starts with.
+func (abi *ABI) ErrorById(data []byte) (*Error, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("data too short (%d bytes) for abi error lookup", len(data))
+	}
+	for _, e := range abi.Errors {
+		if bytes.Equal(e.Id(), data[:4]) {
+			return &e, nil
+		}
+	}
+	return nil, fmt.Errorf("no error with id: %#x", data[:4])
+}
+
+// UnpackRevert decodes the message of revert data from require or revert,
+// which is encoded as Error(string).
+func UnpackRevert(data []byte) (string, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], revertSelector) {
+		return "", fmt.Errorf("abi: revert data is not an Error(string)")
+	}
+	typ, _ := NewType("string", nil)
+	values, err := (Arguments{{Type: typ}}).UnpackValues(data[4:])
+	if err != nil {
+		return "", err
+	}
+	return values[0].(string), nil
+}
+
+// UnpackPanic decodes the code of revert data from a failed assert or a
+// runtime error, which is encoded as Panic(uint256).
+func UnpackPanic(data []byte) (*big.Int, error) {
+	if len(data) != 4+32 || !bytes.Equal(data[:4], panicSelector) {
+		return nil, fmt.Errorf("abi: revert data is not a Panic(uint256)")
+	}
+	return new(big.Int).SetBytes(data[4:]), nil
+}
+
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array accessed",
+	0x31: "out-of-bounds array access; popping on an empty array",
+	0x32: "out-of-bounds access of an array or bytesN",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+// PanicReason describes a Panic(uint256) code.
+func PanicReason(code *big.Int) string {
+	if code.IsUint64() {
+		if reason, ok := panicReasons[code.Uint64()]; ok {
+			return reason
+		}
+	}
+	return "unknown panic code"
+}
@@ -0,0 +1,50 @@
+package abi
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestUnpackRevert(t *testing.T) {
+	abi, err := ParseHumanReadable(
+		"error Error(string)",
+		"error Panic(uint256)",
+		"error InsufficientBalance(uint256 available, uint256 required)",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(name string, args ...interface{}) []byte {
+		e := abi.Errors[name]
+		data, err := e.Inputs.Pack(args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(e.Id(), data...)
+	}
+
+	reason, err := UnpackRevert(encode("Error", "not owner"))
+	if err != nil || reason != "not owner" {
+		t.Errorf("UnpackRevert = %q, %v", reason, err)
+	}
+	code, err := UnpackPanic(encode("Panic", big.NewInt(0x11)))
+	if err != nil || code.Int64() != 0x11 || PanicReason(code) != "arithmetic underflow or overflow" {
+		t.Errorf("UnpackPanic = %v, %v", code, err)
+	}
+	if _, err := UnpackRevert(encode("Panic", big.NewInt(1))); err == nil {
+		t.Error("a panic unpacked as a revert message")
+	}
+
+	data := encode("InsufficientBalance", big.NewInt(1), big.NewInt(2))
+	e, err := abi.ErrorById(data)
+	if err != nil || e.Name != "InsufficientBalance" {
+		t.Fatalf("ErrorById = %v, %v", e, err)
+	}
+	args, err := e.Unpack(data)
+	if err != nil || len(args) != 2 || args[1].(*big.Int).Int64() != 2 {
+		t.Errorf("Unpack = %v, %v", args, err)
+	}
+	if e.String() != "error InsufficientBalance(uint256 available, uint256 required)" {
+		t.Errorf("String = %s", e)
+	}
+}
@@ -0,0 +1,161 @@
+package abi
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"web3.go/web3/dto"
+)
+
+// UnpackLog unpacks a log emitted by the named event into out, which must be
+// a pointer to a struct. Non-indexed arguments are read from the log's data
+// and indexed arguments from its topics.
+//
+// Indexed arguments of a dynamic type (string, bytes, arrays and tuples) are
+// stored in the log as the keccak256 hash of their encoding, so they unpack
+// into a common.Hash.
+func (abi ABI) UnpackLog(out interface{}, name string, log dto.Log) error {
+	event, topics, err := abi.eventTopics(name, log)
+	if err != nil {
+		return err
+	}
+	if event.Inputs.LengthNonIndexed() > 0 {
+		if err := event.Inputs.Unpack(out, log.Data); err != nil {
+			return err
+		}
+	}
+	value := reflect.ValueOf(out)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("abi: UnpackLog(non-pointer to struct %T)", out)
+	}
+	return parseTopics(value.Elem(), indexed(event.Inputs), topics)
+}
+
+// UnpackLogIntoMap unpacks a log emitted by the named event into out, keyed
+// by argument name, like UnpackLog.
+func (abi ABI) UnpackLogIntoMap(out map[string]interface{}, name string, log dto.Log) error {
+	if out == nil {
+		return fmt.Errorf("abi: cannot unpack into a nil map")
+	}
+	event, topics, err := abi.eventTopics(name, log)
+	if err != nil {
+		return err
+	}
+	if err := event.Inputs.UnpackIntoMap(out, log.Data); err != nil {
+		return err
+	}
+	args := indexed(event.Inputs)
+	for i, arg := range args {
+		value, err := topicValue(arg, topics[i])
+		if err != nil {
+			return err
+		}
+		out[arg.Name] = value
+	}
+	return nil
+}
+
+// eventTopics looks the event up and returns the log's topics holding its
+// indexed arguments, checking the signature topic of non-anonymous events.
+func (abi ABI) eventTopics(name string, log dto.Log) (Event, []common.Hash, error) {
+	event, ok := abi.Events[name]
+	if !ok {
+		return Event{}, nil, fmt.Errorf("abi: could not locate event %q", name)
+	}
+	topics := log.Topics
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.Id() {
+			return Event{}, nil, fmt.Errorf("abi: log is not a %s event", name)
+		}
+		topics = topics[1:]
+	}
+	if want := len(indexed(event.Inputs)); len(topics) != want {
+		return Event{}, nil, fmt.Errorf("abi: event %s has %d indexed arguments, log has %d topics", name, want, len(topics))
+	}
+	return event, topics, nil
+}
+
+func indexed(arguments Arguments) Arguments {
+	var ret Arguments
+	for _, arg := range arguments {
+		if arg.Indexed {
+			ret = append(ret, arg)
+		}
+	}
+	return ret
+}
+
+func parseTopics(out reflect.Value, args Arguments, topics []common.Hash) error {
+	names := make([]string, len(args))
+	for i, arg := range args {
+		names[i] = arg.Name
+	}
+	fields, err := mapArgNamesToStructFields(names, out)
+	if err != nil {
+		return err
+	}
+	for i, arg := range args {
+		field := out.FieldByName(fields[arg.Name])
+		if !field.IsValid() {
+			return fmt.Errorf("abi: field %s can't be found in the given value", arg.Name)
+		}
+		value, err := topicValue(arg, topics[i])
+		if err != nil {
+			return err
+		}
+		if err := set(field, reflect.ValueOf(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MakeTopic encodes value as the topic of an indexed argument of type t, for
+// matching logs. Strings and bytes are hashed. Arrays and tuples can only be
+// matched by the common.Hash of their encoding, which is also accepted for
+// strings and bytes.
+func MakeTopic(t Type, value interface{}) (common.Hash, error) {
+	if hash, ok := value.(common.Hash); ok && hashedTopic(t) {
+		return hash, nil
+	}
+	v := indirect(reflect.ValueOf(value))
+	switch t.T {
+	case StringTy:
+		if v.Kind() != reflect.String {
+			return common.Hash{}, typeErr(t.Kind, v.Kind())
+		}
+		return crypto.Keccak256Hash([]byte(v.String())), nil
+	case BytesTy:
+		if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Uint8 {
+			return common.Hash{}, typeErr(t.Type, v.Type())
+		}
+		return crypto.Keccak256Hash(v.Bytes()), nil
+	case SliceTy, ArrayTy, TupleTy:
+		return common.Hash{}, fmt.Errorf("abi: cannot make a topic for %v", t)
+	}
+	packed, err := t.pack(v)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(packed), nil
+}
+
+// hashedTopic reports whether indexed arguments of type t are stored as the
+// hash of their value.
+func hashedTopic(t Type) bool {
+	switch t.T {
+	case StringTy, BytesTy, SliceTy, ArrayTy, TupleTy:
+		return true
+	}
+	return false
+}
+
+// topicValue decodes a single indexed argument from its topic.
+func topicValue(arg Argument, topic common.Hash) (interface{}, error) {
+	if hashedTopic(arg.Type) {
+		return topic, nil
+	}
+	return toGoType(0, arg.Type, topic[:])
+}
@@ -0,0 +1,93 @@
+package abi
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"web3.go/web3/dto"
+)
+
+const eventsJSON = `[
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256"}]},
+	{"type":"event","name":"Named","inputs":[{"name":"name","type":"string","indexed":true},{"name":"id","type":"int64","indexed":true},{"name":"ok","type":"bool","indexed":true}]},
+	{"type":"event","name":"Raw","anonymous":true,"inputs":[{"name":"tag","type":"bytes32","indexed":true},{"name":"memo","type":"string"}]}
+]`
+
+func word(v int64) common.Hash {
+	return common.BigToHash(big.NewInt(v))
+}
+
+func TestUnpackLog(t *testing.T) {
+	abi, err := JSON(strings.NewReader(eventsJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := common.HexToAddress("0x2c7536e3605d9c16a7a3d7b1898e529396a65c23")
+	to := common.HexToAddress("0x6ea0fefc17c877c7a4b0f139728ed39dc134a967")
+	transfer := dto.Log{
+		Topics: []common.Hash{abi.Events["Transfer"].Id(), common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:   word(42).Bytes(),
+	}
+
+	var out struct {
+		From  common.Address
+		To    common.Address
+		Value *big.Int
+	}
+	if err := abi.UnpackLog(&out, "Transfer", transfer); err != nil {
+		t.Fatal(err)
+	}
+	if out.From != from || out.To != to || out.Value.Int64() != 42 {
+		t.Errorf("unpacked %+v", out)
+	}
+
+	values := make(map[string]interface{})
+	if err := abi.UnpackLogIntoMap(values, "Transfer", transfer); err != nil {
+		t.Fatal(err)
+	}
+	if values["from"] != from || values["to"] != to || values["value"].(*big.Int).Int64() != 42 {
+		t.Errorf("unpacked %v", values)
+	}
+
+	if err := abi.UnpackLog(&out, "Named", transfer); err == nil {
+		t.Error("a Transfer log unpacked as Named")
+	}
+}
+
+func TestUnpackLogHashedAndAnonymous(t *testing.T) {
+	abi, err := JSON(strings.NewReader(eventsJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nameHash := crypto.Keccak256Hash([]byte("alice"))
+	named := dto.Log{Topics: []common.Hash{abi.Events["Named"].Id(), nameHash, common.BigToHash(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(7))), word(1)}}
+	var out struct {
+		Name common.Hash
+		Id   int64
+		Ok   bool
+	}
+	if err := abi.UnpackLog(&out, "Named", named); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != nameHash || out.Id != -7 || !out.Ok {
+		t.Errorf("unpacked %+v", out)
+	}
+
+	// Anonymous events have no signature topic.
+	tag := crypto.Keccak256Hash([]byte("tag"))
+	data, err := abi.Events["Raw"].Inputs.NonIndexed().Pack("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]interface{})
+	if err := abi.UnpackLogIntoMap(values, "Raw", dto.Log{Topics: []common.Hash{tag}, Data: data}); err != nil {
+		t.Fatal(err)
+	}
+	if values["tag"] != [32]byte(tag) || values["memo"] != "hello" {
+		t.Errorf("unpacked %v", values)
+	}
+}
@@ -37,6 +37,8 @@ type Type struct {
 	// Tuple relative fields
 	TupleElems    []*Type  // Type information of all tuple fields
 	TupleRawNames []string // Raw field name of all tuple fields
+
+	components []ArgumentMarshaling // as declared, to marshal tuples back
 }
 
 var (
@@ -184,6 +186,7 @@ func NewType(t string, components []ArgumentMarshaling) (typ Type, err error) {
 		typ.TupleRawNames = names
 		typ.T = TupleTy
 		typ.stringKind = expression
+		typ.components = components
 	case "function":
 		typ.Kind = reflect.Array
 		typ.T = FunctionTy
@@ -0,0 +1,491 @@
+// Package backends provides stand-ins for a Thinkey node.
+package backends
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"web3.go/common/cryp/crypto"
+	"web3.go/common/hexutil"
+	"web3.go/web3/dto"
+	"web3.go/web3/providers"
+	"web3.go/web3/thk"
+	"web3.go/web3/thk/util"
+)
+
+// SimulatedBackend is an in-process node for unit tests. It keeps accounts per
+// chain, checks the signature, nonce and balance of every SendTx and applies
+// value transfers at once. Transactions are included in a block, and become
+// visible to GetTransactionByHash, when Commit is called.
+//
+// Only plain transfers within one chain are supported: contract creation and
+// cross-chain transactions are rejected, and input data is recorded but not
+// executed. Transaction and block hashes are derived locally and do not match
+// the ones a real node would report.
+type SimulatedBackend struct {
+	mu     sync.Mutex
+	chains map[int]*simChain
+	txs    map[string]*simTx // by lower-case hash
+}
+
+type simChain struct {
+	id       int
+	accounts map[string]*simAccount // by lower-case address
+	blocks   []dto.GetBlockResult
+	pending  []*simTx
+	included []*simTx
+}
+
+type simAccount struct {
+	nonce   uint64
+	balance *big.Int
+}
+
+type simTx struct {
+	tx        util.Transaction
+	hash      string
+	nonce     uint64
+	value     *big.Int
+	height    int
+	timestamp int64
+	included  bool
+}
+
+// NewSimulatedBackend creates the given chains, each with a genesis block at
+// height 0.
+func NewSimulatedBackend(chainIds ...int) *SimulatedBackend {
+	backend := new(SimulatedBackend)
+	backend.chains = make(map[int]*simChain)
+	backend.txs = make(map[string]*simTx)
+	now := time.Now().Unix()
+	for _, id := range chainIds {
+		chain := &simChain{id: id, accounts: make(map[string]*simAccount)}
+		chain.blocks = append(chain.blocks, chain.mint(nil, now))
+		backend.chains[id] = chain
+	}
+	return backend
+}
+
+// Fund adds amount to the balance of address on chainId.
+func (backend *SimulatedBackend) Fund(chainId int, address string, amount *big.Int) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	chain, ok := backend.chains[chainId]
+	if !ok {
+		return fmt.Errorf("unknown chain %d", chainId)
+	}
+	if _, err := parseAddress(address); err != nil {
+		return err
+	}
+	account := chain.account(address)
+	account.balance.Add(account.balance, amount)
+	return nil
+}
+
+// Commit mints one block on every chain, including the transactions sent to
+// it since the previous Commit.
+func (backend *SimulatedBackend) Commit() {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	now := time.Now().Unix()
+	for _, chain := range backend.chains {
+		height := len(chain.blocks)
+		for _, tx := range chain.pending {
+			tx.height = height
+			tx.timestamp = now
+			tx.included = true
+		}
+		chain.blocks = append(chain.blocks, chain.mint(chain.pending, now))
+		chain.included = append(chain.included, chain.pending...)
+		chain.pending = nil
+	}
+}
+
+func (backend *SimulatedBackend) SendRequest(v interface{}, method string, params interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return &providers.ProviderError{Method: method, Err: err}
+	}
+
+	backend.mu.Lock()
+	var res interface{}
+	switch method {
+	case "GetAccount":
+		res, err = backend.getAccount(body)
+	case "SendTx":
+		res, err = backend.sendTx(body)
+	case "GetTransactionByHash":
+		res, err = backend.getTransactionByHash(body)
+	case "GetBlockHeader":
+		res, err = backend.getBlockHeader(body)
+	case "GetStats":
+		res, err = backend.getStats(body)
+	case "GetMultiStats":
+		res, err = backend.getMultiStats(body)
+	case "GetTransactions":
+		res, err = backend.getTransactions(body)
+	case "GetBlockTxs":
+		res, err = backend.getBlockTxs(body)
+	default:
+		err = errors.New("method not supported by the simulated backend")
+	}
+	backend.mu.Unlock()
+
+	if nodeErr, ok := err.(nodeError); ok {
+		res, err = map[string]string{"ErrMsg": string(nodeErr)}, nil
+	}
+	if err != nil {
+		return &providers.ProviderError{Method: method, Err: err}
+	}
+	out, err := json.Marshal(res)
+	if err != nil {
+		return &providers.ProviderError{Method: method, Err: err}
+	}
+	if err := json.Unmarshal(out, v); err != nil {
+		return &providers.ProviderError{Method: method, Body: out, Err: err}
+	}
+	return nil
+}
+
+func (backend *SimulatedBackend) Close() error {
+	return nil
+}
+
+// nodeError is a rejection the node reports in the ErrMsg field of its answer.
+type nodeError string
+
+func (e nodeError) Error() string {
+	return string(e)
+}
+
+func (backend *SimulatedBackend) chain(chainId string) (*simChain, error) {
+	id, err := strconv.Atoi(chainId)
+	if err != nil {
+		return nil, nodeError(fmt.Sprintf("invalid chainId %q", chainId))
+	}
+	return backend.chainById(id)
+}
+
+func (backend *SimulatedBackend) chainById(id int) (*simChain, error) {
+	chain, ok := backend.chains[id]
+	if !ok {
+		return nil, nodeError(fmt.Sprintf("chain %d not found", id))
+	}
+	return chain, nil
+}
+
+func (backend *SimulatedBackend) getAccount(body []byte) (interface{}, error) {
+	var params util.GetAccountJson
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, err
+	}
+	chain, err := backend.chain(params.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseAddress(params.Address); err != nil {
+		return nil, err
+	}
+	// Looking an account up does not create it.
+	account, ok := chain.accounts[strings.ToLower(params.Address)]
+	if !ok {
+		account = &simAccount{balance: new(big.Int)}
+	}
+	return map[string]interface{}{
+		"address":     params.Address,
+		"nonce":       account.nonce,
+		"balance":     account.balance,
+		"storageRoot": nil,
+		"codeHash":    nil,
+	}, nil
+}
+
+func (backend *SimulatedBackend) sendTx(body []byte) (interface{}, error) {
+	var tx util.Transaction
+	if err := json.Unmarshal(body, &tx); err != nil {
+		return nil, err
+	}
+	chain, err := backend.chain(tx.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	if (tx.FromChainId != "" && tx.FromChainId != tx.ChainId) || (tx.ToChainId != "" && tx.ToChainId != tx.ChainId) {
+		return nil, nodeError("cross-chain transactions are not supported by the simulated backend")
+	}
+	if tx.To == "" {
+		return nil, nodeError("contract creation is not supported by the simulated backend")
+	}
+	if _, err := parseAddress(tx.To); err != nil {
+		return nil, err
+	}
+	from, err := parseAddress(tx.From)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := thk.SigningHash(&tx)
+	sig, err := hexutil.Decode(tx.Sig)
+	if err != nil {
+		return nil, nodeError("invalid sig: " + err.Error())
+	}
+	pub, err := hexutil.Decode(tx.Pub)
+	if err != nil {
+		return nil, nodeError("invalid pub: " + err.Error())
+	}
+	signer, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return nil, nodeError("invalid signature: " + err.Error())
+	}
+	if !bytes.Equal(crypto.FromECDSAPub(signer), pub) || crypto.PubkeyToAddress(*signer) != from {
+		return nil, nodeError("signature does not match from address")
+	}
+
+	nonce, err := strconv.ParseUint(tx.Nonce, 10, 64)
+	if err != nil {
+		return nil, nodeError(fmt.Sprintf("invalid nonce %q", tx.Nonce))
+	}
+	value, ok := new(big.Int).SetString(tx.Value, 10)
+	if !ok || value.Sign() < 0 {
+		return nil, nodeError(fmt.Sprintf("invalid value %q", tx.Value))
+	}
+	sender := chain.account(tx.From)
+	if nonce != sender.nonce {
+		return nil, nodeError(fmt.Sprintf("invalid nonce: expected %d, got %d", sender.nonce, nonce))
+	}
+	if sender.balance.Cmp(value) < 0 {
+		return nil, nodeError("insufficient balance")
+	}
+
+	sender.nonce++
+	sender.balance.Sub(sender.balance, value)
+	recipient := chain.account(tx.To)
+	recipient.balance.Add(recipient.balance, value)
+
+	record := &simTx{tx: tx, nonce: nonce, value: value}
+	record.hash = hexutil.Encode(crypto.Keccak256(hash, sig))
+	backend.txs[record.hash] = record
+	chain.pending = append(chain.pending, record)
+	return map[string]string{"TXhash": record.hash}, nil
+}
+
+func (backend *SimulatedBackend) getTransactionByHash(body []byte) (interface{}, error) {
+	var params util.GetTxByHash
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, err
+	}
+	if _, err := backend.chain(params.ChainId); err != nil {
+		return nil, err
+	}
+	record, ok := backend.txs[strings.ToLower(params.Hash)]
+	if !ok || !record.included || record.tx.ChainId != params.ChainId {
+		return nil, nodeError("transaction not found")
+	}
+	return map[string]interface{}{
+		"Transaction":     record.transaction(),
+		"root":            nil,
+		"status":          1,
+		"logs":            nil,
+		"transactionHash": record.hash,
+		"contractAddress": "",
+		"out":             "0x",
+		"blockHeight":     record.height,
+	}, nil
+}
+
+func (backend *SimulatedBackend) getBlockHeader(body []byte) (interface{}, error) {
+	var params util.GetBlockHeader
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, err
+	}
+	chain, err := backend.chain(params.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	height, err := strconv.Atoi(params.Height)
+	if err != nil || height < 0 || height >= len(chain.blocks) {
+		return nil, nodeError(fmt.Sprintf("block %s not found", params.Height))
+	}
+	return chain.blocks[height], nil
+}
+
+func (backend *SimulatedBackend) getStats(body []byte) (interface{}, error) {
+	var params util.GetStatsJson
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, err
+	}
+	chain, err := backend.chainById(params.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	return chain.stats(), nil
+}
+
+func (backend *SimulatedBackend) getMultiStats(body []byte) (interface{}, error) {
+	var params util.GetMultiStatsJson
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, err
+	}
+	if _, err := backend.chain(params.ChainId); err != nil {
+		return nil, err
+	}
+	list := make([]dto.GetChainStats, 0, len(backend.chains))
+	for _, chain := range backend.chains {
+		list = append(list, chain.stats())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ChainId < list[j].ChainId })
+	return list, nil
+}
+
+func (backend *SimulatedBackend) getTransactions(body []byte) (interface{}, error) {
+	var params util.GetTransactionsJson
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, err
+	}
+	chain, err := backend.chain(params.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	address := strings.ToLower(params.Address)
+	start, end := 0, len(chain.blocks)-1
+	if params.StartHeight != "" {
+		if start, err = strconv.Atoi(params.StartHeight); err != nil {
+			return nil, nodeError(fmt.Sprintf("invalid startHeight %q", params.StartHeight))
+		}
+	}
+	if params.EndHeight != "" {
+		if end, err = strconv.Atoi(params.EndHeight); err != nil {
+			return nil, nodeError(fmt.Sprintf("invalid endHeight %q", params.EndHeight))
+		}
+	}
+
+	list := make([]map[string]interface{}, 0)
+	for _, record := range chain.included {
+		if record.height < start || record.height > end {
+			continue
+		}
+		if strings.ToLower(record.tx.From) != address && strings.ToLower(record.tx.To) != address {
+			continue
+		}
+		entry := record.transaction()
+		entry["hash"] = record.hash
+		entry["timestamp"] = record.timestamp
+		list = append(list, entry)
+	}
+	return list, nil
+}
+
+func (backend *SimulatedBackend) getBlockTxs(body []byte) (interface{}, error) {
+	var params util.GetBlockTxsJson
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, err
+	}
+	chain, err := backend.chain(params.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	height, err := strconv.Atoi(params.Height)
+	if err != nil || height < 0 || height >= len(chain.blocks) {
+		return nil, nodeError(fmt.Sprintf("block %s not found", params.Height))
+	}
+	page, err := strconv.Atoi(params.Page)
+	if err != nil || page < 1 {
+		return nil, nodeError(fmt.Sprintf("invalid page %q", params.Page))
+	}
+	size, err := strconv.Atoi(params.Size)
+	if err != nil || size < 1 {
+		return nil, nodeError(fmt.Sprintf("invalid size %q", params.Size))
+	}
+
+	var inBlock []*simTx
+	for _, record := range chain.included {
+		if record.height == height {
+			inBlock = append(inBlock, record)
+		}
+	}
+	changes := make([]map[string]interface{}, 0)
+	for i := (page - 1) * size; i < page*size && i < len(inBlock); i++ {
+		record := inBlock[i]
+		entry := record.transaction()
+		delete(entry, "chainId")
+		entry["chainid"] = chain.id
+		entry["height"] = record.height
+		entry["hash"] = record.hash
+		entry["timestamp"] = record.timestamp
+		changes = append(changes, entry)
+	}
+	return map[string]interface{}{"elections": nil, "accountchanges": changes}, nil
+}
+
+func (chain *simChain) account(address string) *simAccount {
+	key := strings.ToLower(address)
+	account, ok := chain.accounts[key]
+	if !ok {
+		account = &simAccount{balance: new(big.Int)}
+		chain.accounts[key] = account
+	}
+	return account
+}
+
+func (chain *simChain) stats() dto.GetChainStats {
+	return dto.GetChainStats{
+		ChainId:       chain.id,
+		Currentheight: len(chain.blocks) - 1,
+		Txcount:       len(chain.included),
+		Accountcount:  len(chain.accounts),
+		Currentcomm:   []string{},
+	}
+}
+
+// mint builds the header of the next block holding txs.
+func (chain *simChain) mint(txs []*simTx, timestamp int64) dto.GetBlockResult {
+	block := dto.GetBlockResult{
+		Chainid:   chain.id,
+		Height:    len(chain.blocks),
+		Txcount:   len(txs),
+		Timestamp: timestamp,
+	}
+	if block.Height > 0 {
+		block.Previoushash = chain.blocks[block.Height-1].Hash
+	}
+	hashed := [][]byte{[]byte(fmt.Sprintf("%d/%d/%s", block.Chainid, block.Height, block.Previoushash))}
+	for _, tx := range txs {
+		hashed = append(hashed, []byte(tx.hash))
+	}
+	block.Hash = crypto.Keccak256Hash(hashed...).Hex()
+	block.Mergeroot = crypto.Keccak256Hash().Hex()
+	block.Deltaroot = block.Mergeroot
+	block.Stateroot = block.Mergeroot
+	return block
+}
+
+func (record *simTx) transaction() map[string]interface{} {
+	chainId, _ := strconv.Atoi(record.tx.ChainId)
+	return map[string]interface{}{
+		"chainId": chainId,
+		"from":    strings.ToLower(record.tx.From),
+		"to":      strings.ToLower(record.tx.To),
+		"nonce":   record.nonce,
+		"value":   record.value,
+		"input":   record.tx.Input,
+	}
+}
+
+func parseAddress(address string) (common.Address, error) {
+	b, err := hexutil.Decode(address)
+	if err != nil || len(b) != common.AddressLength {
+		return common.Address{}, nodeError(fmt.Sprintf("invalid address %q", address))
+	}
+	return common.BytesToAddress(b), nil
+}
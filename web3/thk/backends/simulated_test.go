@@ -0,0 +1,128 @@
+package backends
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+
+	"web3.go/common/cryp/crypto"
+	"web3.go/web3/thk"
+	"web3.go/web3/thk/util"
+)
+
+const (
+	testKey  = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testFrom = "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
+	testTo   = "0x6ea0fefc17c877c7a4b0f139728ed39dc134a967"
+)
+
+func TestSimulatedBackendTransfer(t *testing.T) {
+	backend := NewSimulatedBackend(2)
+	if err := backend.Fund(2, testFrom, big.NewInt(1000)); err != nil {
+		t.Fatal(err)
+	}
+	client := thk.NewThk(backend)
+	key, _ := crypto.HexToECDSA(testKey)
+
+	send := func(nonce int64, value string) (string, error) {
+		tx := util.Transaction{
+			ChainId: "2", FromChainId: "2", ToChainId: "2", From: testFrom,
+			To: testTo, Value: value, Input: "", Nonce: strconv.FormatInt(nonce, 10),
+		}
+		if err := client.SignTransaction(&tx, key); err != nil {
+			t.Fatal(err)
+		}
+		return client.SendTx(&tx)
+	}
+
+	hash, err := send(0, "300")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := send(0, "1"); err == nil {
+		t.Error("reused nonce accepted")
+	}
+	if _, err := send(1, "701"); err == nil {
+		t.Error("overdraft accepted")
+	}
+	if _, err := client.GetTransactionByHash("2", hash); err == nil {
+		t.Error("transaction found before Commit")
+	}
+	backend.Commit()
+
+	receipt, err := client.GetTransactionByHash("2", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != 1 || receipt.BlockHeight != 1 || receipt.Transaction.Value != 300 {
+		t.Errorf("unexpected receipt %+v", receipt)
+	}
+	if bal, _ := client.GetBalance(testTo, "2"); bal.Int64() != 300 {
+		t.Errorf("recipient balance %v, want 300", bal)
+	}
+	if bal, _ := client.GetBalance(testFrom, "2"); bal.Int64() != 700 {
+		t.Errorf("sender balance %v, want 700", bal)
+	}
+	if nonce, _ := client.GetNonce(testFrom, "2"); nonce != 1 {
+		t.Errorf("sender nonce %d, want 1", nonce)
+	}
+
+	block, err := client.GetBlockHeader("2", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis, _ := client.GetBlockHeader("2", "0")
+	if block.Txcount != 1 || block.Previoushash != genesis.Hash {
+		t.Errorf("unexpected block %+v", block)
+	}
+	if _, err := client.GetBlockHeader("2", "2"); err == nil {
+		t.Error("block 2 found before it was minted")
+	}
+
+	txs, err := client.GetTransactions("2", testTo, "0", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 1 || txs[0].Hash != hash {
+		t.Errorf("GetTransactions = %+v, want the one transfer", txs)
+	}
+
+	huge, _ := new(big.Int).SetString("1267650600228229401496703205376", 10)
+	backend.Fund(2, testTo, huge)
+	account, err := client.GetAccount(testTo, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := new(big.Int).Add(huge, big.NewInt(300)); account.Balance.Big().Cmp(want) != 0 {
+		t.Errorf("GetAccount balance %v, want %v", account.Balance.Big(), want)
+	}
+
+	stats, err := client.GetStats(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.ChainId != 2 || stats.Currentheight != 1 || stats.Txcount != 1 || stats.Accountcount != 2 {
+		t.Errorf("GetStats = %+v", stats)
+	}
+}
+
+func TestSimulatedBackendRejectsBadSignature(t *testing.T) {
+	backend := NewSimulatedBackend(2)
+	backend.Fund(2, testFrom, big.NewInt(1000))
+	client := thk.NewThk(backend)
+	key, _ := crypto.HexToECDSA(testKey)
+
+	tx := util.Transaction{ChainId: "2", From: testFrom, To: testTo, Value: "1", Nonce: "0"}
+	client.SignTransaction(&tx, key)
+	tx.Value = "2"
+	if _, err := client.SendTx(&tx); err == nil {
+		t.Error("transaction with a tampered value accepted")
+	}
+
+	other, _ := crypto.GenerateKey()
+	tx.Value = "1"
+	client.SignTransaction(&tx, other)
+	if _, err := client.SendTx(&tx); err == nil {
+		t.Error("transaction signed by another key accepted")
+	}
+}
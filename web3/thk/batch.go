@@ -0,0 +1,119 @@
+package thk
+
+import (
+	"context"
+	"reflect"
+
+	"web3.go/web3/dto"
+	"web3.go/web3/providers"
+	"web3.go/web3/thk/util"
+)
+
+// Batch collects requests and sends them to the node together. Results are
+// written to the values passed when queueing; per-request errors are returned
+// by Errors in queue order.
+type Batch struct {
+	thk   *Thk
+	elems []providers.BatchElem
+
+	// Parallelism bounds the requests in flight if the node can't take the
+	// batch in one request. Zero means providers.DefaultBatchParallelism.
+	Parallelism int
+}
+
+func (thk *Thk) Batch() *Batch {
+	return &Batch{thk: thk}
+}
+
+// Queue adds a raw request. result must be a pointer the response can be
+// decoded into.
+func (batch *Batch) Queue(method string, params interface{}, result interface{}) *Batch {
+	batch.elems = append(batch.elems, providers.BatchElem{Method: method, Params: params, Result: result})
+	return batch
+}
+
+// GetBlockHeader queues a GetBlockHeader request. Invalid arguments are not
+// sent; the request fails with their error instead.
+func (batch *Batch) GetBlockHeader(chainId string, height string, result *dto.GetBlockResult) *Batch {
+	params := new(util.GetBlockHeader)
+	if err := params.FormatParams(chainId, height); err != nil {
+		return batch.queueFailed("GetBlockHeader", err)
+	}
+	return batch.Queue("GetBlockHeader", params, result)
+}
+
+// GetTransactionByHash queues a GetTransactionByHash request, checking its
+// arguments like GetBlockHeader.
+func (batch *Batch) GetTransactionByHash(chainId string, hash string, result *dto.TxResult) *Batch {
+	params := new(util.GetTxByHash)
+	if err := params.FormatParams(chainId, hash); err != nil {
+		return batch.queueFailed("GetTransactionByHash", err)
+	}
+	return batch.Queue("GetTransactionByHash", params, result)
+}
+
+// queueFailed adds a request that already failed, keeping Errors in queue
+// order.
+func (batch *Batch) queueFailed(method string, err error) *Batch {
+	batch.elems = append(batch.elems, providers.BatchElem{Method: method, Error: err})
+	return batch
+}
+
+func (batch *Batch) Len() int {
+	return len(batch.elems)
+}
+
+func (batch *Batch) Send() error {
+	return batch.SendContext(context.Background())
+}
+
+// SendContext sends every queued request. The returned error is set only if
+// the batch as a whole could not be sent, and is then also the error of each
+// request left without a result.
+func (batch *Batch) SendContext(ctx context.Context) error {
+	var (
+		send    []providers.BatchElem
+		indexes []int
+	)
+	for i, elem := range batch.elems {
+		if elem.Error == nil {
+			send = append(send, elem)
+			indexes = append(indexes, i)
+		}
+	}
+	err := providers.SendBatchContext(ctx, batch.thk.provider, send, batch.Parallelism)
+	for j, i := range indexes {
+		elem := &batch.elems[i]
+		elem.Error = send[j].Error
+		if elem.Error == nil {
+			elem.Error = resultNodeError(elem.Method, elem.Result)
+		}
+	}
+	return err
+}
+
+// Errors returns the error of each queued request, nil for those that
+// succeeded.
+func (batch *Batch) Errors() []error {
+	errs := make([]error, len(batch.elems))
+	for i, elem := range batch.elems {
+		errs[i] = elem.Error
+	}
+	return errs
+}
+
+// resultNodeError reports the ErrMsg carried by a decoded result, whether it
+// was decoded into a map or into a dto struct with an ErrMsg field.
+func resultNodeError(method string, result interface{}) error {
+	if res, ok := result.(*map[string]interface{}); ok {
+		return mapNodeError(method, *res)
+	}
+	value := reflect.Indirect(reflect.ValueOf(result))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	if field := value.FieldByName("ErrMsg"); field.Kind() == reflect.String && field.String() != "" {
+		return &providers.NodeError{Method: method, Message: field.String()}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package thk_test
+
+import (
+	"context"
+	"testing"
+
+	"web3.go/web3/dto"
+	"web3.go/web3/providers"
+	"web3.go/web3/thk"
+)
+
+// failingBatches fails every batch as a whole, the way an HTTP provider does
+// when the node can't be reached.
+type failingBatches struct{ rawProvider }
+
+func (failingBatches) SendBatchContext(ctx context.Context, batch []providers.BatchElem, parallelism int) error {
+	err := &providers.ProviderError{Method: "batch", StatusCode: 502}
+	for i := range batch {
+		batch[i].Error = err
+	}
+	return err
+}
+
+func TestBatchFailure(t *testing.T) {
+	var (
+		header dto.GetBlockResult
+		tx     dto.TxResult
+	)
+	batch := thk.NewThk(failingBatches{}).Batch().
+		GetBlockHeader("2", "1", &header).
+		GetTransactionByHash("2", "0x01", &tx)
+	err := batch.Send()
+	if err == nil {
+		t.Fatal("the failed batch reported no error")
+	}
+	for i, reqErr := range batch.Errors() {
+		if reqErr != err {
+			t.Errorf("request %d failed with %v, want the batch error %v", i, reqErr, err)
+		}
+	}
+}
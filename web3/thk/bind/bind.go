@@ -0,0 +1,288 @@
+// Package bind generates Go bindings for Thinkey contracts from their ABI.
+// The generated code is built on thk.Contract.
+package bind
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"web3.go/web3/thk/abi"
+)
+
+// Bind returns the source of package pkg holding a binding named typeName for
+// the contract with the JSON ABI abiJSON. When bytecode is not empty a
+// Deploy<typeName> function is generated as well.
+func Bind(pkg string, typeName string, abiJSON string, bytecode string) (string, error) {
+	if !token.IsIdentifier(pkg) {
+		return "", fmt.Errorf("invalid package name %q", pkg)
+	}
+	if !token.IsIdentifier(typeName) || !token.IsExported(typeName) {
+		return "", fmt.Errorf("invalid type name %q", typeName)
+	}
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return "", err
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(abiJSON)); err != nil {
+		return "", err
+	}
+	if bytecode = strings.TrimSpace(bytecode); bytecode != "" && !strings.HasPrefix(bytecode, "0x") {
+		bytecode = "0x" + bytecode
+	}
+
+	b := &binder{typeName: typeName, structNames: make(map[string]string)}
+	data := &contractData{
+		Package: pkg,
+		Type:    typeName,
+		ABI:     strconv.Quote(compact.String()),
+		Bin:     strconv.Quote(bytecode),
+		Deploy:  bytecode != "",
+	}
+	for name := range parsed.Events {
+		// Keep the event structs' names off the tuple structs.
+		b.structNames["event "+name] = typeName + abi.ToCamelCase(name)
+	}
+	if data.Constructor, err = b.method(parsed.Constructor); err != nil {
+		return "", err
+	}
+	methods := make([]string, 0, len(parsed.Methods))
+	for name := range parsed.Methods {
+		methods = append(methods, name)
+	}
+	sort.Strings(methods)
+	for _, name := range methods {
+		method, err := b.method(parsed.Methods[name])
+		if err != nil {
+			return "", err
+		}
+		if parsed.Methods[name].IsConstant() {
+			data.Calls = append(data.Calls, method)
+		} else {
+			data.Transacts = append(data.Transacts, method)
+		}
+	}
+	names := make([]string, 0, len(parsed.Events))
+	for name := range parsed.Events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		event, err := b.event(parsed.Events[name])
+		if err != nil {
+			return "", err
+		}
+		data.Events = append(data.Events, event)
+	}
+	data.Structs = b.structs
+
+	var buf bytes.Buffer
+	if err := contractTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("formatting generated code: %v\n%s", err, buf.Bytes())
+	}
+	return string(code), nil
+}
+
+type contractData struct {
+	Package     string
+	Type        string
+	ABI         string // quoted Go string literals
+	Bin         string
+	Deploy      bool
+	Constructor methodData
+	Calls       []methodData
+	Transacts   []methodData
+	Events      []eventData
+	Structs     []structData
+}
+
+type methodData struct {
+	GoName  string
+	Sig     string
+	Inputs  []argData
+	Outputs []argData
+}
+
+type eventData struct {
+	GoName  string
+	Name    string
+	Fields  []argData
+	Indexed []argData // filter parameters
+}
+
+type structData struct {
+	Name   string
+	Fields []argData
+}
+
+type argData struct {
+	Name string
+	Type string
+}
+
+// binder maps ABI types to Go types, collecting the structs generated for
+// tuples along the way.
+type binder struct {
+	typeName    string
+	structNames map[string]string // tuple signature and field names to struct name
+	structs     []structData
+}
+
+// reserved are the identifiers generated functions use themselves.
+var reserved = map[string]bool{
+	"ctx": true, "client": true, "transaction": true, "key": true, "address": true,
+	"receipt": true, "contract": true, "hash": true, "err": true,
+	"event": true, "events": true, "log": true, "logs": true,
+}
+
+func (b *binder) method(method abi.Method) (methodData, error) {
+	data := methodData{GoName: abi.ToCamelCase(method.Name), Sig: method.Sig()}
+	for i, input := range method.Inputs {
+		typ, err := b.goType(input.Type, input.Name)
+		if err != nil {
+			return methodData{}, err
+		}
+		data.Inputs = append(data.Inputs, argData{Name: paramName(input.Name, i), Type: typ})
+	}
+	for i, output := range method.Outputs {
+		typ, err := b.goType(output.Type, output.Name)
+		if err != nil {
+			return methodData{}, err
+		}
+		data.Outputs = append(data.Outputs, argData{Name: "ret" + strconv.Itoa(i), Type: typ})
+	}
+	return data, nil
+}
+
+func (b *binder) event(event abi.Event) (eventData, error) {
+	data := eventData{GoName: abi.ToCamelCase(event.Name), Name: event.Name}
+	for i, input := range event.Inputs {
+		field := abi.ToCamelCase(input.Name)
+		if field == "" || field == "Raw" {
+			return eventData{}, fmt.Errorf("event %s: argument %d needs a name other than %q", event.Name, i, input.Name)
+		}
+		typ, err := b.goType(input.Type, input.Name)
+		if err != nil {
+			return eventData{}, err
+		}
+		if !input.Indexed {
+			data.Fields = append(data.Fields, argData{Name: field, Type: typ})
+			continue
+		}
+		filterType := typ
+		switch input.Type.T {
+		case abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+			// Only their hash is in the log.
+			typ, filterType = "common.Hash", "common.Hash"
+		case abi.StringTy, abi.BytesTy:
+			typ = "common.Hash"
+		}
+		data.Fields = append(data.Fields, argData{Name: field, Type: typ})
+		data.Indexed = append(data.Indexed, argData{Name: paramName(input.Name, i), Type: filterType})
+	}
+	return data, nil
+}
+
+// goType returns the Go type abi.Arguments.Copy and UnpackLog store values
+// of type t into. hint names the struct generated for a tuple.
+func (b *binder) goType(t abi.Type, hint string) (string, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		return t.Type.String(), nil
+	case abi.BoolTy:
+		return "bool", nil
+	case abi.StringTy:
+		return "string", nil
+	case abi.AddressTy:
+		return "common.Address", nil
+	case abi.HashTy:
+		return "common.Hash", nil
+	case abi.BytesTy:
+		return "[]byte", nil
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("[%d]byte", t.Size), nil
+	case abi.FunctionTy:
+		return "[24]byte", nil
+	case abi.SliceTy:
+		elem, err := b.goType(*t.Elem, hint)
+		return "[]" + elem, err
+	case abi.ArrayTy:
+		elem, err := b.goType(*t.Elem, hint)
+		return fmt.Sprintf("[%d]%s", t.Size, elem), err
+	case abi.TupleTy:
+		return b.tuple(t, hint)
+	}
+	return "", fmt.Errorf("unsupported type %v", t)
+}
+
+func (b *binder) tuple(t abi.Type, hint string) (string, error) {
+	sig := fmt.Sprintf("%v%v", t, t.TupleRawNames)
+	if name, ok := b.structNames[sig]; ok {
+		return name, nil
+	}
+	base := b.typeName + abi.ToCamelCase(strings.TrimLeft(hint, "_"))
+	if base == b.typeName {
+		base += "Tuple"
+	}
+	name := base
+	for i := 0; b.structTaken(name); i++ {
+		name = base + strconv.Itoa(i)
+	}
+	b.structNames[sig] = name
+
+	data := structData{Name: name}
+	for i, elem := range t.TupleElems {
+		typ, err := b.goType(*elem, t.TupleRawNames[i])
+		if err != nil {
+			return "", err
+		}
+		data.Fields = append(data.Fields, argData{Name: abi.ToCamelCase(t.TupleRawNames[i]), Type: typ})
+	}
+	b.structs = append(b.structs, data)
+	return name, nil
+}
+
+func (b *binder) structTaken(name string) bool {
+	for _, taken := range b.structNames {
+		if taken == name {
+			return true
+		}
+	}
+	return false
+}
+
+// paramName makes an ABI argument name usable as a Go parameter.
+func paramName(name string, index int) string {
+	if name == "" || token.IsKeyword(name) || reserved[name] || !token.IsIdentifier(name) {
+		return "arg" + strconv.Itoa(index)
+	}
+	return name
+}
+
+var contractTemplate = template.Must(template.New("contract").Funcs(template.FuncMap{
+	"params": func(args []argData) string {
+		var out string
+		for _, arg := range args {
+			out += ", " + arg.Name + " " + arg.Type
+		}
+		return out
+	},
+	"args": func(args []argData) string {
+		var out string
+		for _, arg := range args {
+			out += ", " + arg.Name
+		}
+		return out
+	},
+}).Parse(contractSource))
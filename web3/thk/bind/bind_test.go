@@ -0,0 +1,66 @@
+package bind
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const tokenABI = `[
+	{"type":"constructor","inputs":[{"name":"supply","type":"uint256"}]},
+	{"type":"function","name":"balanceOf","constant":true,"inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"info","constant":true,"inputs":[],"outputs":[{"name":"name","type":"string"},{"name":"order","type":"tuple","components":[{"name":"orderId","type":"string"},{"name":"qty","type":"uint64"}]}]},
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"key","type":"string"}],"outputs":[]},
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256"}]}
+]`
+
+func TestBind(t *testing.T) {
+	code, err := Bind("token", "Token", tokenABI, "6080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "token.go", code, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, code)
+	}
+	for _, want := range []string{
+		`const TokenBin = "0x6080"`,
+		"func DeployToken(ctx context.Context, client *thk.Thk, transaction util.Transaction, key *ecdsa.PrivateKey, supply *big.Int) (*Token, *dto.TxResult, error)",
+		"func (_Token *Token) BalanceOf(ctx context.Context, transaction util.Transaction, owner common.Address) (*big.Int, error)",
+		"func (_Token *Token) Info(ctx context.Context, transaction util.Transaction) (string, TokenOrder, error)",
+		"type TokenOrder struct {\n\tOrderId string\n\tQty     uint64\n}",
+		`_Token.contract.SendContext(ctx, transaction, "transfer(address,uint256)", key, to, value)`,
+		// The overload's argument named key is renamed.
+		"func (_Token *Token) Transfer0(ctx context.Context, transaction util.Transaction, key *ecdsa.PrivateKey, to common.Address, arg1 string) (string, error)",
+		"type TokenTransfer struct {\n\tFrom  common.Address\n\tTo    common.Address\n\tValue *big.Int\n\tRaw   dto.Log\n}",
+		"func (_Token *Token) FilterTransfer(receipt *dto.TxResult, from []common.Address, to []common.Address) ([]*TokenTransfer, error)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code is missing %q", want)
+		}
+	}
+
+	code, err = Bind("token", "Token", tokenABI, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(code, "DeployToken") {
+		t.Error("deploy function generated without bytecode")
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	for _, tt := range []struct {
+		pkg, typeName, abi string
+	}{
+		{"my-token", "Token", tokenABI},
+		{"token", "token", tokenABI},
+		{"token", "Token", `[{"type":"event","name":"E","inputs":[{"name":"","type":"uint256"}]}]`},
+		{"token", "Token", `[{"type":"function","name":"f","inputs":[{"name":"x","type":"uint"}]}]`},
+	} {
+		if _, err := Bind(tt.pkg, tt.typeName, tt.abi, ""); err == nil {
+			t.Errorf("Bind(%s, %s, %s) succeeded", tt.pkg, tt.typeName, tt.abi)
+		}
+	}
+}
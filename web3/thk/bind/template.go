@@ -0,0 +1,147 @@
+package bind
+
+// contractSource is the template of a generated binding, executed with a
+// *contractData.
+const contractSource = `// Code generated by abigen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"web3.go/web3/dto"
+	"web3.go/web3/thk"
+	"web3.go/web3/thk/util"
+)
+
+// Reference imports that a contract may not need.
+var (
+	_ = context.Background
+	_ = (*ecdsa.PrivateKey)(nil)
+	_ = big.NewInt
+	_ = common.Address{}
+	_ = dto.Log{}
+	_ = util.Transaction{}
+)
+
+// {{.Type}}ABI is the ABI the binding was generated from.
+const {{.Type}}ABI = {{.ABI}}
+{{if .Deploy}}
+// {{.Type}}Bin is the contract's creation bytecode.
+const {{.Type}}Bin = {{.Bin}}
+{{end}}
+{{range .Structs}}
+// {{.Name}} is a tuple used by the contract's ABI.
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{end}}
+// {{.Type}} is a binding to a deployed {{.Type}} contract.
+type {{.Type}} struct {
+	address  string
+	contract *thk.Contract
+}
+
+// New{{.Type}} binds the contract deployed at address.
+func New{{.Type}}(client *thk.Thk, address string) (*{{.Type}}, error) {
+	contract, err := client.NewContract({{.Type}}ABI)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}{address: address, contract: contract}, nil
+}
+{{if .Deploy}}
+// Deploy{{.Type}} deploys a new {{.Type}} contract and waits for it to be
+// included, returning the binding with its receipt.
+func Deploy{{.Type}}(ctx context.Context, client *thk.Thk, transaction util.Transaction, key *ecdsa.PrivateKey{{params .Constructor.Inputs}}) (*{{.Type}}, *dto.TxResult, error) {
+	contract, err := client.NewContract({{.Type}}ABI)
+	if err != nil {
+		return nil, nil, err
+	}
+	hash, err := contract.DeployContext(ctx, transaction, {{.Type}}Bin, key{{args .Constructor.Inputs}})
+	if err != nil {
+		return nil, nil, err
+	}
+	receipt, err := contract.WaitForTx(ctx, transaction.ChainId, hash)
+	if err != nil {
+		return nil, receipt, err
+	}
+	return &{{.Type}}{address: receipt.ContractAddress, contract: contract}, receipt, nil
+}
+{{end}}
+// Address returns the address the binding calls.
+func (_{{$.Type}} *{{.Type}}) Address() string {
+	return _{{$.Type}}.address
+}
+
+// Contract returns the underlying contract.
+func (_{{$.Type}} *{{.Type}}) Contract() *thk.Contract {
+	return _{{$.Type}}.contract
+}
+{{range .Calls}}
+// {{.GoName}} calls {{.Sig}} without sending a transaction.
+func (_{{$.Type}} *{{$.Type}}) {{.GoName}}(ctx context.Context, transaction util.Transaction{{params .Inputs}}) ({{range .Outputs}}{{.Type}}, {{end}}error) {
+{{- range .Outputs}}
+	{{.Name}} := new({{.Type}})
+{{- end}}
+	transaction.To = _{{$.Type}}.address
+	err := _{{$.Type}}.contract.CallInto(ctx, transaction, "{{.Sig}}", []interface{}{ {{- range $i, $out := .Outputs}}{{if $i}}, {{end}}{{$out.Name}}{{end -}} }{{args .Inputs}})
+	return {{range .Outputs}}*{{.Name}}, {{end}}err
+}
+{{end}}
+{{- range .Transacts}}
+// {{.GoName}} sends a transaction calling {{.Sig}}, returning its hash.
+func (_{{$.Type}} *{{$.Type}}) {{.GoName}}(ctx context.Context, transaction util.Transaction, key *ecdsa.PrivateKey{{params .Inputs}}) (string, error) {
+	transaction.To = _{{$.Type}}.address
+	return _{{$.Type}}.contract.SendContext(ctx, transaction, "{{.Sig}}", key{{args .Inputs}})
+}
+{{end}}
+{{- range .Events}}
+// {{$.Type}}{{.GoName}} is a {{.Name}} event emitted by {{$.Type}}.
+type {{$.Type}}{{.GoName}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+	Raw dto.Log
+}
+
+// Parse{{.GoName}} decodes a {{.Name}} log.
+func (_{{$.Type}} *{{$.Type}}) Parse{{.GoName}}(log dto.Log) (*{{$.Type}}{{.GoName}}, error) {
+	event := new({{$.Type}}{{.GoName}})
+	if err := _{{$.Type}}.contract.ABI().UnpackLog(event, "{{.Name}}", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// Filter{{.GoName}} returns the {{.Name}} events in receipt emitted by the
+// bound contract.{{if .Indexed}} Each rule lists the values an indexed argument
+// may have; an empty rule accepts any value.{{end}}
+func (_{{$.Type}} *{{$.Type}}) Filter{{.GoName}}(receipt *dto.TxResult{{range .Indexed}}, {{.Name}} []{{.Type}}{{end}}) ([]*{{$.Type}}{{.GoName}}, error) {
+{{- range .Indexed}}
+	var {{.Name}}Rule []interface{}
+	for _, {{.Name}}Item := range {{.Name}} {
+		{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+	}
+{{- end}}
+	logs, err := _{{$.Type}}.contract.FilterLogs(receipt, _{{$.Type}}.address, "{{.Name}}"{{range .Indexed}}, {{.Name}}Rule{{end}})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*{{$.Type}}{{.GoName}}, 0, len(logs))
+	for _, log := range logs {
+		event, err := _{{$.Type}}.Parse{{.GoName}}(log)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+{{end}}`
@@ -0,0 +1,95 @@
+package thk
+
+import (
+	"context"
+	"strconv"
+
+	"web3.go/web3/dto"
+)
+
+// DefaultBlockTxsPageSize is the page size BlockTxs uses when none is given.
+const DefaultBlockTxsPageSize = 100
+
+// BlockTxIterator walks the transactions of a range of blocks, fetching them a
+// page at a time:
+//
+//	it := thk.BlockTxs(ctx, "2", 100, 200, 0)
+//	for it.Next() {
+//		tx := it.Tx()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type BlockTxIterator struct {
+	thk      *Thk
+	ctx      context.Context
+	chainId  string
+	height   int
+	end      int
+	pageSize int
+
+	page    int // last page fetched for height, 0 before the first
+	buf     []dto.BlockTx
+	lastLen int // size of the last page fetched
+	tx      dto.BlockTx
+	err     error
+}
+
+// BlockTxs returns an iterator over the transactions of blocks startHeight to
+// endHeight inclusive on chainId, in block order. A pageSize of zero means
+// DefaultBlockTxsPageSize.
+func (thk *Thk) BlockTxs(ctx context.Context, chainId string, startHeight, endHeight int, pageSize int) *BlockTxIterator {
+	if pageSize <= 0 {
+		pageSize = DefaultBlockTxsPageSize
+	}
+	return &BlockTxIterator{
+		thk:      thk,
+		ctx:      ctx,
+		chainId:  chainId,
+		height:   startHeight,
+		end:      endHeight,
+		pageSize: pageSize,
+	}
+}
+
+// Next advances to the next transaction, fetching pages as needed. It returns
+// false once the range is exhausted or a request failed; Err tells which.
+func (it *BlockTxIterator) Next() bool {
+	for len(it.buf) == 0 {
+		if it.err != nil || it.height > it.end {
+			return false
+		}
+		if it.page > 0 && it.lastLen < it.pageSize {
+			// A short page is the block's last one.
+			it.height++
+			it.page = 0
+			continue
+		}
+		res, err := it.thk.GetBlockTxsContext(it.ctx, it.chainId, strconv.Itoa(it.height), strconv.Itoa(it.page+1), strconv.Itoa(it.pageSize))
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page++
+		it.buf = res.AccountChanges
+		it.lastLen = len(res.AccountChanges)
+	}
+	it.tx, it.buf = it.buf[0], it.buf[1:]
+	return true
+}
+
+// Tx returns the transaction Next advanced to.
+func (it *BlockTxIterator) Tx() dto.BlockTx {
+	return it.tx
+}
+
+// Height returns the block the current transaction belongs to.
+func (it *BlockTxIterator) Height() int {
+	return it.height
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *BlockTxIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,76 @@
+package thk_test
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"web3.go/common/cryp/crypto"
+	"web3.go/web3/thk"
+	"web3.go/web3/thk/backends"
+	"web3.go/web3/thk/util"
+)
+
+func TestBlockTxs(t *testing.T) {
+	const (
+		from = "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
+		to   = "0x6ea0fefc17c877c7a4b0f139728ed39dc134a967"
+	)
+	backend := backends.NewSimulatedBackend(2)
+	backend.Fund(2, from, big.NewInt(1000))
+	client := thk.NewThk(backend)
+	key, _ := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+
+	// Blocks 1 to 3 hold 5, 0 and 3 transactions.
+	var hashes []string
+	nonce := 0
+	for _, count := range []int{5, 0, 3} {
+		for i := 0; i < count; i++ {
+			tx := util.Transaction{ChainId: "2", From: from, To: to, Value: "1", Nonce: strconv.Itoa(nonce)}
+			client.SignTransaction(&tx, key)
+			hash, err := client.SendTx(&tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hashes = append(hashes, hash)
+			nonce++
+		}
+		backend.Commit()
+	}
+
+	page, err := client.GetBlockTxs("2", "1", "2", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.AccountChanges) != 2 || page.AccountChanges[0].Hash != hashes[2] {
+		t.Errorf("page 2 of block 1 = %+v", page.AccountChanges)
+	}
+
+	it := client.BlockTxs(context.Background(), "2", 1, 3, 2)
+	var got []string
+	for it.Next() {
+		if h := it.Height(); h != it.Tx().Height {
+			t.Errorf("iterator at height %d, transaction from %d", h, it.Tx().Height)
+		}
+		got = append(got, it.Tx().Hash)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(hashes) {
+		t.Fatalf("iterated %d transactions, want %d", len(got), len(hashes))
+	}
+	for i := range got {
+		if got[i] != hashes[i] {
+			t.Errorf("transaction %d = %s, want %s", i, got[i], hashes[i])
+		}
+	}
+
+	it = client.BlockTxs(context.Background(), "2", 3, 9, 0)
+	for it.Next() {
+	}
+	if it.Err() == nil {
+		t.Error("iterating past the chain head should fail")
+	}
+}
@@ -1,8 +1,8 @@
 package thk
 
 import (
+	"context"
 	"crypto/ecdsa"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -11,49 +11,19 @@ import (
 	"web3.go/web3/dto"
 	"web3.go/web3/thk/abi"
 	"web3.go/web3/thk/util"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 type Contract struct {
-	super     *Thk
-	abi       abi.ABI
-	functions map[string][]string
+	super *Thk
+	abi   abi.ABI
 }
 
 //新合约
 func (thk *Thk) NewContract(abistr string) (*Contract, error) {
 
 	contract := new(Contract)
-	var mockInterface interface{}
-
-	err := json.Unmarshal([]byte(abistr), &mockInterface)
-
-	if err != nil {
-		return nil, err
-	}
-
-	jsonInterface := mockInterface.([]interface{})
-	contract.functions = make(map[string][]string)
-	for index := 0; index < len(jsonInterface); index++ {
-		function := jsonInterface[index].(map[string]interface{})
-
-		if function["type"] == "constructor" || function["type"] == "fallback" {
-			function["name"] = function["type"]
-		}
-
-		functionName := function["name"].(string)
-		contract.functions[functionName] = make([]string, 0)
-
-		if function["inputs"] == nil {
-			continue
-		}
-
-		inputs := function["inputs"].([]interface{})
-		for paramIndex := 0; paramIndex < len(inputs); paramIndex++ {
-			params := inputs[paramIndex].(map[string]interface{})
-			contract.functions[functionName] = append(contract.functions[functionName], params["type"].(string))
-		}
-
-	}
 	readerstr := strings.NewReader(abistr)
 	Abi, err := abi.JSON(readerstr)
 	if err != nil {
@@ -65,6 +35,12 @@ func (thk *Thk) NewContract(abistr string) (*Contract, error) {
 	return contract, nil
 }
 
+// NewContractFromABI returns a contract for an ABI parsed already, such as
+// one built with abi.ParseHumanReadable.
+func (thk *Thk) NewContractFromABI(parsed abi.ABI) *Contract {
+	return &Contract{super: thk, abi: parsed}
+}
+
 func (contract *Contract) getHexValue(inputType string, value interface{}) (string, error) {
 
 	var data string
@@ -104,11 +80,23 @@ func (contract *Contract) getHexValue(inputType string, value interface{}) (stri
 
 }
 
-//
+// Send packs a call to functionName and sends it signed. functionName can be
+// a signature such as "transfer(address,uint256)" to pick an overload;
+// otherwise the overload is chosen from the types of args.
 func (contract *Contract) Send(transaction util.Transaction, functionName string, privatekey *ecdsa.PrivateKey, args ...interface{}) (string, error) {
+	return contract.SendContext(context.Background(), transaction, functionName, privatekey, args...)
+}
 
-	// transaction, err := contract.prepareTransaction(transaction, functionName, args)
-	fixedArrStrPack, err := contract.abi.Pack(functionName, args...)
+func (contract *Contract) SendContext(ctx context.Context, transaction util.Transaction, functionName string, privatekey *ecdsa.PrivateKey, args ...interface{}) (string, error) {
+
+	method, err := contract.abi.ResolveMethod(functionName, args...)
+	if err != nil {
+		return "", err
+	}
+	if !method.IsPayable() && hasValue(transaction.Value) {
+		return "", fmt.Errorf("%s is not payable, but the transaction has value %s", method.Sig(), transaction.Value)
+	}
+	fixedArrStrPack, err := contract.abi.Pack(method.Sig(), args...)
 	if err != nil {
 		return "", err
 	}
@@ -116,12 +104,19 @@ func (contract *Contract) Send(transaction util.Transaction, functionName string
 	if err = contract.super.SignTransaction(&transaction, privatekey); err != nil {
 		return "", err
 	}
-	return contract.super.SendTx(&transaction)
+	return contract.super.SendTxContext(ctx, &transaction)
 
 }
 
 func (contract *Contract) Deploy(transaction util.Transaction, bytecode string, privatekey *ecdsa.PrivateKey, args ...interface{}) (string, error) {
+	return contract.DeployContext(context.Background(), transaction, bytecode, privatekey, args...)
+}
+
+func (contract *Contract) DeployContext(ctx context.Context, transaction util.Transaction, bytecode string, privatekey *ecdsa.PrivateKey, args ...interface{}) (string, error) {
 
+	if constructor := contract.abi.Constructor; constructor.Type == abi.Constructor && !constructor.IsPayable() && hasValue(transaction.Value) {
+		return "", fmt.Errorf("constructor is not payable, but the transaction has value %s", transaction.Value)
+	}
 	fixedArrStrPack, err := contract.abi.Pack("", args...)
 	if err != nil {
 		return "", err
@@ -131,10 +126,17 @@ func (contract *Contract) Deploy(transaction util.Transaction, bytecode string,
 	if err != nil {
 		return "", err
 	}
-	return contract.super.SendTx(&transaction)
+	return contract.super.SendTxContext(ctx, &transaction)
 }
 
+// Call runs functionName without sending a transaction. It resolves
+// overloads like Send. A call that fails returns its result along with a
+// *ContractRevertError.
 func (contract *Contract) Call(transaction util.Transaction, functionName string, args ...interface{}) (*dto.TxResult, error) {
+	return contract.CallContext(context.Background(), transaction, functionName, args...)
+}
+
+func (contract *Contract) CallContext(ctx context.Context, transaction util.Transaction, functionName string, args ...interface{}) (*dto.TxResult, error) {
 
 	// transaction, err := contract.prepareTransaction(transaction, functionName, args)
 	fixedArrStrPack, err := contract.abi.Pack(functionName, args...)
@@ -142,16 +144,201 @@ func (contract *Contract) Call(transaction util.Transaction, functionName string
 		return nil, err
 	}
 	transaction.Input = hexutil.Encode(fixedArrStrPack)
-	return contract.super.CallTransaction(&transaction)
+	res, err := contract.super.CallTransactionContext(ctx, &transaction)
+	if err != nil {
+		return nil, err
+	}
+	if res.Status != 1 {
+		return res, newRevertError(&contract.abi, res)
+	}
+	return res, nil
+}
 
+// Invoke runs functionName the way its ABI declares it: a view or pure
+// function is called without a transaction, anything else is sent signed
+// with privatekey and waited for. Either way the result is returned.
+func (contract *Contract) Invoke(ctx context.Context, transaction util.Transaction, functionName string, privatekey *ecdsa.PrivateKey, args ...interface{}) (*dto.TxResult, error) {
+	method, err := contract.abi.ResolveMethod(functionName, args...)
+	if err != nil {
+		return nil, err
+	}
+	if method.IsConstant() {
+		return contract.CallContext(ctx, transaction, method.Sig(), args...)
+	}
+	hash, err := contract.SendContext(ctx, transaction, method.Sig(), privatekey, args...)
+	if err != nil {
+		return nil, err
+	}
+	return contract.WaitForTx(ctx, transaction.ChainId, hash)
+}
+
+// hasValue reports whether a transaction's decimal value is above zero.
+func hasValue(value string) bool {
+	amount, ok := new(big.Int).SetString(value, 10)
+	return ok && amount.Sign() > 0
+}
+
+// WaitForTx waits for a transaction sent to the contract like Thk.WaitForTx,
+// decoding the contract's custom errors when it failed.
+func (contract *Contract) WaitForTx(ctx context.Context, chainId string, hash string) (*dto.TxResult, error) {
+	res, err := contract.super.WaitForTx(ctx, chainId, hash)
+	var failed *TxFailedError
+	if errors.As(err, &failed) {
+		failed.Revert = newRevertError(&contract.abi, failed.Receipt)
+	}
+	return res, err
 }
 
 //解析
 func (contract *Contract) Parse(callRes *dto.TxResult, name string, args interface{}) error {
 	res, err := hexutil.Decode(callRes.Out)
-	if err = contract.abi.Unpack(args, name, res); err != nil {
+	if err != nil {
+		return err
+	}
+	return contract.abi.Unpack(args, name, res)
+}
+
+// DecodeTx decodes the call a transaction made to the contract from its
+// input.
+func (contract *Contract) DecodeTx(tx dto.TxResult) (*abi.Method, []abi.DecodedArgument, error) {
+	return contract.DecodeInput(tx.Transaction.Input)
+}
+
+// DecodeInput decodes the hex input of a transaction to the contract, such
+// as the Input of an entry returned by GetTransactions.
+func (contract *Contract) DecodeInput(input string) (*abi.Method, []abi.DecodedArgument, error) {
+	data, err := hexutil.Decode(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding transaction input: %v", err)
+	}
+	return contract.abi.DecodeCalldata(data)
+}
+
+// ABI returns the contract's parsed ABI.
+func (contract *Contract) ABI() abi.ABI {
+	return contract.abi
+}
+
+// CallInto calls functionName like Call and stores its outputs into out, one
+// pointer per output.
+func (contract *Contract) CallInto(ctx context.Context, transaction util.Transaction, functionName string, out []interface{}, args ...interface{}) error {
+	method, err := contract.abi.ResolveMethod(functionName, args...)
+	if err != nil {
 		return err
-	} else {
-		return nil
 	}
+	res, err := contract.CallContext(ctx, transaction, method.Sig(), args...)
+	if err != nil {
+		return err
+	}
+	data, err := hexutil.Decode(res.Out)
+	if err != nil {
+		return fmt.Errorf("decoding output of %s: %v", method.Sig(), err)
+	}
+	values, err := method.Outputs.UnpackValues(data)
+	if err != nil {
+		return err
+	}
+	return method.Outputs.Copy(out, values)
+}
+
+// FilterLogs returns the receipt's logs that the contract at address emitted
+// as eventName. query holds, for each indexed argument in order, the values
+// to accept; an empty rule accepts any value.
+func (contract *Contract) FilterLogs(receipt *dto.TxResult, address string, eventName string, query ...[]interface{}) ([]dto.Log, error) {
+	event, ok := contract.abi.Events[eventName]
+	if !ok {
+		return nil, fmt.Errorf("event '%s' not found", eventName)
+	}
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(query) > len(indexed) {
+		return nil, fmt.Errorf("event %s has %d indexed arguments, got %d rules", eventName, len(indexed), len(query))
+	}
+	rules := make([][]common.Hash, len(query))
+	for i, values := range query {
+		for _, value := range values {
+			topic, err := abi.MakeTopic(indexed[i].Type, value)
+			if err != nil {
+				return nil, fmt.Errorf("rule for %s: %v", indexed[i].Name, err)
+			}
+			rules[i] = append(rules[i], topic)
+		}
+	}
+
+	from := common.HexToAddress(address)
+	var logs []dto.Log
+	for _, log := range receipt.Logs {
+		topics := log.Topics
+		if !event.Anonymous {
+			if len(topics) == 0 || topics[0] != event.Id() {
+				continue
+			}
+			topics = topics[1:]
+		}
+		if log.Address != from || len(topics) != len(indexed) || !matchTopics(topics, rules) {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+func matchTopics(topics []common.Hash, rules [][]common.Hash) bool {
+	for i, rule := range rules {
+		if len(rule) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range rule {
+			if topics[i] == topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// DecodedLog is a receipt log matched to one of the contract's events.
+type DecodedLog struct {
+	Log   dto.Log
+	Event string
+	Args  map[string]interface{}
+}
+
+// DecodeLogs decodes the receipt's logs whose first topic is the signature of
+// one of the contract's events. Other logs are skipped.
+func (contract *Contract) DecodeLogs(receipt *dto.TxResult) ([]DecodedLog, error) {
+	var decoded []DecodedLog
+	for _, log := range receipt.Logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+		event, ok := contract.eventById(log.Topics[0])
+		if !ok {
+			continue
+		}
+		args := make(map[string]interface{})
+		if err := contract.abi.UnpackLogIntoMap(args, event.Name, log); err != nil {
+			return nil, fmt.Errorf("decoding log %d as %s: %v", log.Index, event.Name, err)
+		}
+		decoded = append(decoded, DecodedLog{Log: log, Event: event.Name, Args: args})
+	}
+	return decoded, nil
+}
+
+func (contract *Contract) eventById(id common.Hash) (abi.Event, bool) {
+	for _, event := range contract.abi.Events {
+		if !event.Anonymous && event.Id() == id {
+			return event, true
+		}
+	}
+	return abi.Event{}, false
 }
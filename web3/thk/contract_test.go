@@ -0,0 +1,211 @@
+package thk_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"web3.go/common/hexutil"
+	"web3.go/web3/dto"
+	"web3.go/web3/thk"
+	"web3.go/web3/thk/abi"
+	"web3.go/web3/thk/backends"
+	"web3.go/web3/thk/util"
+)
+
+const tokenABI = `[
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]}
+]`
+
+func TestContractDecodeLogs(t *testing.T) {
+	contract, err := thk.NewThk(backends.NewSimulatedBackend()).NewContract(tokenABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var receipt dto.TxResult
+	err = json.Unmarshal([]byte(`{
+		"status": 1,
+		"root": null,
+		"logs": [
+			{"address": "0x0000000000000000000000000000000000020000", "topics": ["0x0000000000000000000000000000000000000000000000000000000000000001"], "data": "0x", "index": 0},
+			{"address": "0x0e50cea0402d2a396b0db1c5d08155bd219cc52e",
+			 "topics": [
+				"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+				"0x0000000000000000000000002c7536e3605d9c16a7a3d7b1898e529396a65c23",
+				"0x0000000000000000000000006ea0fefc17c877c7a4b0f139728ed39dc134a967"],
+			 "data": "0x000000000000000000000000000000000000000000000000000000000000002a",
+			 "blockNumber": 7, "transactionIndex": 0, "index": 1}
+		]
+	}`), &receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err := contract.DecodeLogs(&receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("decoded %d logs, want only the Transfer", len(logs))
+	}
+	log := logs[0]
+	if log.Event != "Transfer" || log.Log.Index != 1 || log.Log.BlockHeight != 7 {
+		t.Errorf("decoded %+v", log)
+	}
+	if value, ok := log.Args["value"].(*big.Int); !ok || value.Int64() != 42 {
+		t.Errorf("value = %v, want 42", log.Args["value"])
+	}
+	if from, ok := log.Args["from"].(common.Address); !ok || from != common.HexToAddress(testFrom) {
+		t.Errorf("from = %v, want %s", log.Args["from"], testFrom)
+	}
+}
+
+func TestContractCallInto(t *testing.T) {
+	const infoABI = `[{"type":"function","name":"info","constant":true,"inputs":[{"name":"id","type":"uint256"}],"outputs":[{"name":"name","type":"string"},{"name":"order","type":"tuple","components":[{"name":"orderId","type":"string"},{"name":"qty","type":"uint64"}]}]}]`
+	type order struct {
+		OrderId string
+		Qty     uint64
+	}
+	parsed, err := abi.JSON(strings.NewReader(infoABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := parsed.Methods["info"].Outputs.Pack("alice", order{"o-1", 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := thk.NewThk(receiptProvider{map[string]interface{}{"status": 1, "out": hexutil.Encode(out)}})
+	contract, err := client.NewContract(infoABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		name string
+		got  order
+	)
+	if err := contract.CallInto(context.Background(), util.Transaction{}, "info", []interface{}{&name, &got}, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if name != "alice" || got != (order{"o-1", 3}) {
+		t.Errorf("got %q, %+v", name, got)
+	}
+}
+
+func TestContractFilterLogs(t *testing.T) {
+	contract, err := thk.NewThk(backends.NewSimulatedBackend()).NewContract(tokenABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := common.HexToAddress("0x0e50cea0402d2a396b0db1c5d08155bd219cc52e")
+	transfer := contract.ABI().Events["Transfer"].Id()
+	topic := func(address string) common.Hash { return common.BytesToHash(common.HexToAddress(address).Bytes()) }
+	receipt := &dto.TxResult{Logs: []dto.Log{
+		{Address: token, Topics: []common.Hash{transfer, topic(testFrom), topic(testTo)}, Index: 0},
+		{Address: token, Topics: []common.Hash{transfer, topic(testTo), topic(testFrom)}, Index: 1},
+		{Address: common.HexToAddress(testTo), Topics: []common.Hash{transfer, topic(testFrom), topic(testTo)}, Index: 2},
+	}}
+
+	all, err := contract.FilterLogs(receipt, token.Hex(), "Transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Errorf("matched %d logs of the token, want 2", len(all))
+	}
+	logs, err := contract.FilterLogs(receipt, token.Hex(), "Transfer", nil, []interface{}{common.HexToAddress(testFrom)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].Index != 1 {
+		t.Errorf("matched %+v, want only the transfer to %s", logs, testFrom)
+	}
+}
+
+// methodProvider records the methods requested and answers each with the
+// same result.
+type methodProvider struct {
+	result  map[string]interface{}
+	methods *[]string
+}
+
+func (p methodProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	*p.methods = append(*p.methods, method)
+	body, _ := json.Marshal(p.result)
+	return json.Unmarshal(body, v)
+}
+
+func (p methodProvider) Close() error { return nil }
+
+func TestContractPayable(t *testing.T) {
+	const vaultABI = `[
+		{"type":"constructor","stateMutability":"nonpayable","inputs":[]},
+		{"type":"function","name":"deposit","stateMutability":"payable","inputs":[],"outputs":[]},
+		{"type":"function","name":"withdraw","stateMutability":"nonpayable","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]},
+		{"type":"function","name":"balance","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]}
+	]`
+	var methods []string
+	client := thk.NewThk(methodProvider{map[string]interface{}{"status": 1, "out": "0x000000000000000000000000000000000000000000000000000000000000002a", "TXhash": "0x01"}, &methods})
+	contract, err := client.NewContract(vaultABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := crypto.HexToECDSA(testKey)
+	ctx := context.Background()
+	paid := util.Transaction{ChainId: "2", From: testFrom, To: testTo, Nonce: "0", Value: "5"}
+
+	if _, err := contract.SendContext(ctx, paid, "withdraw", key, big.NewInt(1)); err == nil || !strings.Contains(err.Error(), "not payable") {
+		t.Errorf("sending value to withdraw: err = %v, want not payable", err)
+	}
+	if _, err := contract.DeployContext(ctx, paid, "0x6080", key); err == nil || !strings.Contains(err.Error(), "not payable") {
+		t.Errorf("deploying with value: err = %v, want not payable", err)
+	}
+	if len(methods) != 0 {
+		t.Fatalf("refused transactions reached the node: %v", methods)
+	}
+	if _, err := contract.SendContext(ctx, paid, "deposit", key); err != nil {
+		t.Errorf("sending value to deposit: %v", err)
+	}
+
+	methods = nil
+	res, err := contract.Invoke(ctx, util.Transaction{ChainId: "2", From: testFrom, To: testTo}, "balance", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(methods) != 1 || methods[0] != "CallTransaction" || res.Out == "" {
+		t.Errorf("Invoke of a view function requested %v, want only CallTransaction", methods)
+	}
+}
+
+func TestContractDecodeTx(t *testing.T) {
+	contract, err := thk.NewThk(backends.NewSimulatedBackend()).NewContract(tokenABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := contract.ABI().Pack("transfer", common.HexToAddress(testTo), big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := dto.TxResult{Transaction: dto.TransactionResult{From: testFrom, Input: hexutil.Encode(input)}}
+
+	method, args, err := contract.DecodeTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method.Name != "transfer" || len(args) != 2 {
+		t.Fatalf("decoded %s with %v", method.Name, args)
+	}
+	if args[0].Name != "to" || args[0].Value != common.HexToAddress(testTo) || args[1].Value.(*big.Int).Int64() != 7 {
+		t.Errorf("args = %v", args)
+	}
+
+	if _, _, err := contract.DecodeTx(dto.TxResult{}); err == nil {
+		t.Error("decoded a transaction without input")
+	}
+}
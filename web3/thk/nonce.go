@@ -0,0 +1,176 @@
+package thk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"web3.go/web3/providers"
+	"web3.go/web3/thk/util"
+)
+
+// NonceManager hands out nonces for accounts that send from several
+// goroutines at once, so that they don't each call GetNonce and race for the
+// same value. Nonces are tracked per chain and address, seeded from the node
+// the first time an account is used.
+type NonceManager struct {
+	thk *Thk
+
+	mu       sync.Mutex
+	accounts map[nonceKey]*nonceState
+}
+
+type nonceKey struct {
+	chainId string
+	address string
+}
+
+type nonceState struct {
+	mu       sync.Mutex
+	seeded   bool
+	next     uint64
+	released []uint64 // handed out and given back, sorted, all below next
+}
+
+func (thk *Thk) NewNonceManager() *NonceManager {
+	return &NonceManager{thk: thk, accounts: make(map[nonceKey]*nonceState)}
+}
+
+func (manager *NonceManager) state(chainId, address string) *nonceState {
+	key := nonceKey{chainId: chainId, address: strings.ToLower(address)}
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	state, ok := manager.accounts[key]
+	if !ok {
+		state = new(nonceState)
+		manager.accounts[key] = state
+	}
+	return state
+}
+
+// Next returns the nonce to use for the next transaction from address. Nonces
+// given back with Release are reused first, lowest first, so that no gap is
+// left behind.
+func (manager *NonceManager) Next(ctx context.Context, chainId, address string) (uint64, error) {
+	state := manager.state(chainId, address)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if !state.seeded {
+		if err := manager.seed(ctx, state, chainId, address); err != nil {
+			return 0, err
+		}
+	}
+	if len(state.released) > 0 {
+		nonce := state.released[0]
+		state.released = state.released[1:]
+		return nonce, nil
+	}
+	nonce := state.next
+	state.next++
+	return nonce, nil
+}
+
+// Release gives back a nonce whose transaction never reached the node or was
+// rejected by it. The latest nonce is rolled back; an earlier one is kept to
+// fill the gap with the next transaction.
+func (manager *NonceManager) Release(chainId, address string, nonce uint64) {
+	state := manager.state(chainId, address)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if !state.seeded || nonce >= state.next {
+		return
+	}
+	i := sort.Search(len(state.released), func(i int) bool { return state.released[i] >= nonce })
+	if i < len(state.released) && state.released[i] == nonce {
+		return
+	}
+	state.released = append(state.released, 0)
+	copy(state.released[i+1:], state.released[i:])
+	state.released[i] = nonce
+
+	// Released nonces at the top are simply not handed out yet.
+	for n := len(state.released); n > 0 && state.released[n-1] == state.next-1; n-- {
+		state.next--
+		state.released = state.released[:n-1]
+	}
+}
+
+// Resync reloads the nonce of address from the node. Use it after a
+// transaction failed in a way that leaves its fate unknown, or when something
+// else sends from the same account. The sequence only ever moves forward:
+// nonces handed out may still be in flight, so the chain's nonce is taken only
+// when it is ahead, and released nonces it has passed are dropped.
+func (manager *NonceManager) Resync(ctx context.Context, chainId, address string) error {
+	state := manager.state(chainId, address)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return manager.seed(ctx, state, chainId, address)
+}
+
+func (manager *NonceManager) seed(ctx context.Context, state *nonceState, chainId, address string) error {
+	n, err := manager.thk.GetNonceContext(ctx, address, chainId)
+	if err != nil {
+		return err
+	}
+	nonce := uint64(n)
+	if !state.seeded || nonce > state.next {
+		state.seeded = true
+		state.next = nonce
+	}
+	released := state.released[:0]
+	for _, r := range state.released {
+		if r >= nonce {
+			released = append(released, r)
+		}
+	}
+	state.released = released
+	return nil
+}
+
+// SendTx sets the nonce of transaction, signs it and sends it. If the node
+// rejects it the nonce is released, unless the rejection is about the nonce
+// itself: then the nonce was taken already, and the account is resynced from
+// the node instead; a failed resync is reported with the node's error. If the outcome is unknown, for instance on a timeout, the
+// nonce is kept, since the node may have applied the transaction, and the
+// caller should Resync before relying on the sequence again.
+func (manager *NonceManager) SendTx(ctx context.Context, transaction *util.Transaction, privatekey *ecdsa.PrivateKey) (string, error) {
+	nonce, err := manager.Next(ctx, transaction.ChainId, transaction.From)
+	if err != nil {
+		return "", err
+	}
+	transaction.Nonce = strconv.FormatUint(nonce, 10)
+	if err := manager.thk.SignTransaction(transaction, privatekey); err != nil {
+		manager.Release(transaction.ChainId, transaction.From, nonce)
+		return "", err
+	}
+	hash, err := manager.thk.SendTxContext(ctx, transaction)
+	if err != nil {
+		var nodeErr *providers.NodeError
+		switch {
+		case !errors.As(err, &nodeErr):
+		case isNonceError(nodeErr):
+			if resyncErr := manager.Resync(ctx, transaction.ChainId, transaction.From); resyncErr != nil {
+				return "", fmt.Errorf("%w (resyncing the nonce failed: %v)", err, resyncErr)
+			}
+		default:
+			manager.Release(transaction.ChainId, transaction.From, nonce)
+		}
+		return "", err
+	}
+	return hash, nil
+}
+
+// isNonceError reports whether the node rejected a transaction for its nonce,
+// such as one that is too low or already used.
+func isNonceError(err *providers.NodeError) bool {
+	return strings.Contains(strings.ToLower(err.Message), "nonce")
+}
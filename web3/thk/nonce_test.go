@@ -0,0 +1,169 @@
+package thk_test
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"web3.go/common/cryp/crypto"
+	"web3.go/web3/thk"
+	"web3.go/web3/thk/backends"
+	"web3.go/web3/thk/util"
+)
+
+const (
+	testKey  = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testFrom = "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
+	testTo   = "0x6ea0fefc17c877c7a4b0f139728ed39dc134a967"
+)
+
+func TestNonceManagerConcurrent(t *testing.T) {
+	backend := backends.NewSimulatedBackend(2)
+	client := thk.NewThk(backend)
+	manager := client.NewNonceManager()
+	ctx := context.Background()
+
+	const n = 200
+	var (
+		mu     sync.Mutex
+		nonces []uint64
+		wg     sync.WaitGroup
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := manager.Next(ctx, "2", testFrom)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			nonces = append(nonces, nonce)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	for i, nonce := range nonces {
+		if nonce != uint64(i) {
+			t.Fatalf("nonces handed out are not 0..%d without duplicates: %v", n-1, nonces)
+		}
+	}
+
+	// Releasing the last nonce rolls back; releasing an earlier one leaves a
+	// gap that is filled first.
+	manager.Release("2", testFrom, n-1)
+	manager.Release("2", testFrom, 7)
+	for _, want := range []uint64{7, n - 1, n} {
+		if got, _ := manager.Next(ctx, "2", "0x2C7536E3605D9C16A7A3D7B1898E529396A65C23"); got != want {
+			t.Errorf("Next = %d, want %d", got, want)
+		}
+	}
+
+	// The chain is behind, with nonces 0..n in flight: Resync must not hand
+	// them out again.
+	if err := manager.Resync(ctx, "2", testFrom); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := manager.Next(ctx, "2", testFrom); got != n+1 {
+		t.Errorf("Next after Resync = %d, want %d", got, n+1)
+	}
+}
+
+func TestNonceManagerResyncAhead(t *testing.T) {
+	backend := backends.NewSimulatedBackend(2)
+	backend.Fund(2, testFrom, big.NewInt(100))
+	client := thk.NewThk(backend)
+	manager := client.NewNonceManager()
+	key, _ := crypto.HexToECDSA(testKey)
+	ctx := context.Background()
+
+	for _, want := range []uint64{0, 1, 2} {
+		if got, _ := manager.Next(ctx, "2", testFrom); got != want {
+			t.Fatalf("Next = %d, want %d", got, want)
+		}
+	}
+	manager.Release("2", testFrom, 0)
+	manager.Release("2", testFrom, 1)
+	// Something else sends nonces 0 to 3.
+	for i := 0; i < 4; i++ {
+		tx := &util.Transaction{ChainId: "2", From: testFrom, To: testTo, Value: "1", Nonce: strconv.Itoa(i)}
+		client.SignTransaction(tx, key)
+		if _, err := client.SendTx(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := manager.Resync(ctx, "2", testFrom); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := manager.Next(ctx, "2", testFrom); got != 4 {
+		t.Errorf("Next after Resync = %d, want the chain's nonce 4", got)
+	}
+}
+
+func TestNonceManagerSendTx(t *testing.T) {
+	backend := backends.NewSimulatedBackend(2)
+	backend.Fund(2, testFrom, big.NewInt(100))
+	client := thk.NewThk(backend)
+	manager := client.NewNonceManager()
+	key, _ := crypto.HexToECDSA(testKey)
+	ctx := context.Background()
+
+	send := func(value string) error {
+		tx := &util.Transaction{ChainId: "2", From: testFrom, To: testTo, Value: value}
+		_, err := manager.SendTx(ctx, tx, key)
+		return err
+	}
+	if err := send("10"); err != nil {
+		t.Fatal(err)
+	}
+	if err := send("1000"); err == nil {
+		t.Fatal("overdraft accepted")
+	}
+	// The rejected transaction's nonce is reused.
+	if err := send("10"); err != nil {
+		t.Fatal(err)
+	}
+	if nonce, _ := client.GetNonce(testFrom, "2"); nonce != 2 {
+		t.Errorf("account nonce %d, want 2", nonce)
+	}
+}
+
+func TestNonceManagerSendTxNonceRejected(t *testing.T) {
+	backend := backends.NewSimulatedBackend(2)
+	backend.Fund(2, testFrom, big.NewInt(100))
+	client := thk.NewThk(backend)
+	manager := client.NewNonceManager()
+	key, _ := crypto.HexToECDSA(testKey)
+	ctx := context.Background()
+
+	send := func() error {
+		tx := &util.Transaction{ChainId: "2", From: testFrom, To: testTo, Value: "1"}
+		_, err := manager.SendTx(ctx, tx, key)
+		return err
+	}
+	if err := send(); err != nil {
+		t.Fatal(err)
+	}
+	// Another sender uses nonce 1 behind the manager's back.
+	other := &util.Transaction{ChainId: "2", From: testFrom, To: testTo, Value: "1", Nonce: "1"}
+	client.SignTransaction(other, key)
+	if _, err := client.SendTx(other); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nonce 1 is rejected as used; it must not be handed out again.
+	if err := send(); err == nil {
+		t.Fatal("a used nonce was accepted")
+	}
+	if err := send(); err != nil {
+		t.Fatalf("after a nonce rejection: %v", err)
+	}
+	if nonce, _ := client.GetNonce(testFrom, "2"); nonce != 3 {
+		t.Errorf("account nonce %d, want 3", nonce)
+	}
+}
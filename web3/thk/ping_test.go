@@ -0,0 +1,19 @@
+package thk_test
+
+import (
+	"testing"
+
+	"web3.go/web3/thk"
+)
+
+func TestPing(t *testing.T) {
+	nonce, err := thk.NewThk(rawProvider(`{"nonce":7}`)).Ping("2")
+	if err != nil || nonce != 7 {
+		t.Errorf("Ping = %d, %v, want 7", nonce, err)
+	}
+	for _, answer := range []string{`{}`, `{"nonce":"7"}`, `{"ErrMsg":"chain not found"}`} {
+		if _, err := thk.NewThk(rawProvider(answer)).Ping("2"); err == nil {
+			t.Errorf("answer %s accepted", answer)
+		}
+	}
+}
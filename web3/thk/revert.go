@@ -0,0 +1,69 @@
+package thk
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"web3.go/common/hexutil"
+	"web3.go/web3/dto"
+	"web3.go/web3/thk/abi"
+)
+
+// ContractRevertError is a call or transaction that did not succeed, with
+// what the contract reverted with decoded: the message of require or revert,
+// the code of a failed assert or runtime error, or a custom error declared in
+// the contract's ABI.
+type ContractRevertError struct {
+	Status int
+	Data   []byte // raw return data
+
+	Reason    string
+	PanicCode *big.Int
+	ErrorName string // Solidity name of the custom error
+	Args      []interface{}
+}
+
+func (e *ContractRevertError) Error() string {
+	switch {
+	case e.Reason != "":
+		return "execution reverted: " + e.Reason
+	case e.PanicCode != nil:
+		return fmt.Sprintf("execution reverted: panic %#x (%s)", e.PanicCode, abi.PanicReason(e.PanicCode))
+	case e.ErrorName != "":
+		args := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = fmt.Sprint(arg)
+		}
+		return fmt.Sprintf("execution reverted: %s(%s)", e.ErrorName, strings.Join(args, ", "))
+	case len(e.Data) > 0:
+		return "execution reverted: " + hexutil.Encode(e.Data)
+	}
+	return fmt.Sprintf("execution failed with status %d", e.Status)
+}
+
+// newRevertError decodes the output of a failed receipt. Custom errors are
+// only decoded when parsed, the ABI of the contract called, is given.
+func newRevertError(parsed *abi.ABI, receipt *dto.TxResult) *ContractRevertError {
+	e := &ContractRevertError{Status: receipt.Status}
+	if receipt.Out == "" || receipt.Out == "0x" {
+		return e
+	}
+	data, err := hexutil.Decode(receipt.Out)
+	if err != nil {
+		return e
+	}
+	e.Data = data
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		e.Reason = reason
+	} else if code, err := abi.UnpackPanic(data); err == nil {
+		e.PanicCode = code
+	} else if parsed != nil {
+		if custom, err := parsed.ErrorById(data); err == nil {
+			if args, err := custom.Unpack(data); err == nil {
+				e.ErrorName, e.Args = custom.RawName, args
+			}
+		}
+	}
+	return e
+}
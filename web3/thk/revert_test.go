@@ -0,0 +1,60 @@
+package thk_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"web3.go/common/hexutil"
+	"web3.go/web3/thk"
+	"web3.go/web3/thk/abi"
+	"web3.go/web3/thk/util"
+)
+
+func revertData(t *testing.T, parsed abi.ABI, name string, args ...interface{}) string {
+	e := parsed.Errors[name]
+	data, err := e.Inputs.Pack(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hexutil.Encode(append(e.Id(), data...))
+}
+
+func TestContractRevertError(t *testing.T) {
+	parsed, err := abi.ParseHumanReadable(
+		"function withdraw(uint256 amount)",
+		"error Error(string)",
+		"error InsufficientBalance(uint256 available, uint256 required)",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := thk.NewThk(receiptProvider{map[string]interface{}{"status": 0, "out": revertData(t, parsed, "Error", "too much")}})
+	contract := client.NewContractFromABI(parsed)
+	res, err := contract.Call(util.Transaction{}, "withdraw", big.NewInt(5))
+	var revert *thk.ContractRevertError
+	if !errors.As(err, &revert) || revert.Reason != "too much" || res == nil {
+		t.Fatalf("Call = %v, %v, want a revert with its reason", res, err)
+	}
+	if err.Error() != "execution reverted: too much" {
+		t.Errorf("error %q", err)
+	}
+
+	// Custom errors are decoded with the contract's ABI, the standard ones
+	// by the client alone.
+	custom := revertData(t, parsed, "InsufficientBalance", big.NewInt(1), big.NewInt(5))
+	client = thk.NewThk(receiptProvider{map[string]interface{}{"transactionHash": "0x01", "status": 0, "out": custom}})
+	_, err = client.NewContractFromABI(parsed).WaitForTx(context.Background(), "2", "0x01")
+	var failed *thk.TxFailedError
+	if !errors.As(err, &failed) || !errors.As(err, &revert) || revert.ErrorName != "InsufficientBalance" || len(revert.Args) != 2 {
+		t.Fatalf("WaitForTx = %v, want an InsufficientBalance revert", err)
+	}
+	if got, want := err.Error(), "transaction 0x01 failed with status 0: execution reverted: InsufficientBalance(1, 5)"; got != want {
+		t.Errorf("error %q, want %q", got, want)
+	}
+	if _, err = client.WaitForTx(context.Background(), "2", "0x01"); !errors.As(err, &revert) || revert.ErrorName != "" || len(revert.Data) != 68 {
+		t.Errorf("WaitForTx without the ABI = %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package thk_test
+
+import (
+	"testing"
+
+	"web3.go/web3/thk"
+	"web3.go/web3/thk/backends"
+)
+
+func TestGetMultiStats(t *testing.T) {
+	backend := backends.NewSimulatedBackend(3, 1, 2)
+	backend.Commit()
+	backend.Commit()
+	client := thk.NewThk(backend)
+
+	stats, err := client.GetMultiStats("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("got stats for %d chains, want 3", len(stats))
+	}
+	for i, s := range stats {
+		if s.ChainId != i+1 || s.Currentheight != 2 {
+			t.Errorf("stats[%d] = %+v, want chain %d at height 2", i, s, i+1)
+		}
+	}
+
+	if _, err := client.GetStats(9); err == nil {
+		t.Error("stats of an unknown chain should fail")
+	}
+}
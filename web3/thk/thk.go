@@ -1,8 +1,10 @@
 package thk
 
 import (
+	"bytes"
+	"context"
 	"crypto/ecdsa"
-	"errors"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"strings"
@@ -14,6 +16,9 @@ import (
 	"web3.go/web3/thk/util"
 )
 
+// Thk wraps the node's RPC methods. Every method that talks to the node has an
+// XxxContext variant whose ctx bounds the request; the plain form uses
+// context.Background().
 type Thk struct {
 	provider providers.ProviderInterface
 }
@@ -24,64 +29,105 @@ func NewThk(provider providers.ProviderInterface) *Thk {
 	return thk
 }
 
-//获取余额11
-func (thk *Thk) GetBalance(address string, chainId string) (*big.Int, error) {
+func (thk *Thk) sendRequest(ctx context.Context, v interface{}, method string, params interface{}) error {
+	return providers.SendRequestContext(ctx, thk.provider, v, method, params)
+}
+
+// mapNodeError reports the errMsg of an untyped result as a *providers.NodeError.
+func mapNodeError(method string, res map[string]interface{}) error {
+	for _, key := range []string{"errMsg", "ErrMsg"} {
+		if msg, ok := res[key].(string); ok && msg != "" {
+			return &providers.NodeError{Method: method, Message: msg}
+		}
+	}
+	return nil
+}
+
+// GetAccount returns the state of address on chainId, with the balance exact.
+func (thk *Thk) GetAccount(address string, chainId string) (*dto.BalanceResult, error) {
+	return thk.GetAccountContext(context.Background(), address, chainId)
+}
+
+func (thk *Thk) GetAccountContext(ctx context.Context, address string, chainId string) (*dto.BalanceResult, error) {
 	params := new(util.GetAccountJson)
 	if err := params.FormatParams(address, chainId); err != nil {
 		return nil, err
 	}
-	res := make(map[string]interface{})
-	if err := thk.provider.SendRequest(&res, "GetAccount", params); err != nil {
+	res := new(dto.BalanceResult)
+	if err := thk.sendRequest(ctx, res, "GetAccount", params); err != nil {
 		return nil, err
 	}
-
-	if _, ok := res["errMsg"]; ok {
-		return nil, errors.New(res["errMsg"].(string))
+	if res.ErrMsg != "" {
+		err := &providers.NodeError{Method: "GetAccount", Message: res.ErrMsg}
+		return nil, err
 	}
-	ret := big.NewInt(int64(res["balance"].(float64)))
+	return res, nil
+}
 
-	return ret, nil
+//获取余额11
+func (thk *Thk) GetBalance(address string, chainId string) (*big.Int, error) {
+	return thk.GetBalanceContext(context.Background(), address, chainId)
+}
+
+func (thk *Thk) GetBalanceContext(ctx context.Context, address string, chainId string) (*big.Int, error) {
+	res, err := thk.GetAccountContext(ctx, address, chainId)
+	if err != nil {
+		return nil, err
+	}
+	return res.Balance.Big(), nil
 }
 
 //获取之前交易数
 func (thk *Thk) GetNonce(address string, chainId string) (int64, error) {
-	params := new(util.GetAccountJson)
-	if err := params.FormatParams(address, chainId); err != nil {
-		return 0, err
-	}
-	res := make(map[string]interface{})
-	if err := thk.provider.SendRequest(&res, "GetAccount", params); err != nil {
-		return 0, err
-	}
+	return thk.GetNonceContext(context.Background(), address, chainId)
+}
 
-	if _, ok := res["errMsg"]; ok {
-		return 0, errors.New(res["errMsg"].(string))
+func (thk *Thk) GetNonceContext(ctx context.Context, address string, chainId string) (int64, error) {
+	res, err := thk.GetAccountContext(ctx, address, chainId)
+	if err != nil {
+		return 0, err
 	}
-	ret := int64(res["nonce"].(float64))
+	return int64(res.Nonce), nil
+}
 
-	return ret, nil
+// GetBlockTxs returns one page of the transactions of a block. Pages are
+// numbered from 1; use BlockTxs to walk all of them.
+func (thk *Thk) GetBlockTxs(chainId string, height string, page string, size string) (*dto.BlockTxsResult, error) {
+	return thk.GetBlockTxsContext(context.Background(), chainId, height, page, size)
 }
 
-//	获取块交易11
-func (thk *Thk) GetBlockTxs(chainId string, height string, page string, size string) {
+func (thk *Thk) GetBlockTxsContext(ctx context.Context, chainId string, height string, page string, size string) (*dto.BlockTxsResult, error) {
 	params := new(util.GetBlockTxsJson)
 	if err := params.FormatParams(chainId, height, page, size); err != nil {
-		return
+		return nil, err
+	}
+	res := new(dto.BlockTxsResult)
+	if err := thk.sendRequest(ctx, res, "GetBlockTxs", params); err != nil {
+		return nil, err
+	}
+	if res.ErrMsg != "" {
+		err := &providers.NodeError{Method: "GetBlockTxs", Message: res.ErrMsg}
+		return nil, err
 	}
+	return res, nil
 }
 
 //11
 func (thk *Thk) SendTx(transaction *util.Transaction) (string, error) {
+	return thk.SendTxContext(context.Background(), transaction)
+}
+
+func (thk *Thk) SendTxContext(ctx context.Context, transaction *util.Transaction) (string, error) {
 	// params := new(util.Transaction)
 	// if err := params.FormatParams(transaction); err != nil {
 	// 	return err
 	// }
 	res := new(dto.SendTxResult)
-	if err := thk.provider.SendRequest(res, "SendTx", transaction); err != nil {
+	if err := thk.sendRequest(ctx, res, "SendTx", transaction); err != nil {
 		return "", err
 	}
 	if res.ErrMsg != "" {
-		err := errors.New(res.ErrMsg)
+		err := &providers.NodeError{Method: "SendTx", Message: res.ErrMsg}
 		return "", err
 	}
 	return res.TXhash, nil
@@ -89,6 +135,20 @@ func (thk *Thk) SendTx(transaction *util.Transaction) (string, error) {
 
 //交易签名
 func (thk *Thk) SignTransaction(transaction *util.Transaction, privatekey *ecdsa.PrivateKey) error {
+	hash := SigningHash(transaction)
+	sig, err := crypto.Sign(hash, privatekey)
+	if err != nil {
+		return err
+	}
+	transaction.Sig = hexutil.Encode(sig)
+	transaction.Pub = hexutil.Encode(crypto.FromECDSAPub(&privatekey.PublicKey))
+	return nil
+}
+
+// SigningHash returns the hash SignTransaction signs: the keccak256 of the
+// chain id, lower-case from and to addresses, nonce, value and input
+// concatenated as text, without 0x prefixes.
+func SigningHash(transaction *util.Transaction) []byte {
 	var toAddr string
 	var fromAddr string
 	if len(transaction.To) > 2 {
@@ -111,28 +171,22 @@ func (thk *Thk) SignTransaction(transaction *util.Transaction, privatekey *ecdsa
 	str := []string{transaction.ChainId, fromAddr, toAddr, transaction.Nonce, transaction.Value, input}
 	p := strings.Join(str, "")
 	tmp := sha3.NewKeccak256()
-	_, err := tmp.Write([]byte(p))
-	if err != nil {
-		return err
-	}
-	hash := tmp.Sum(nil)
-	sig, err := crypto.Sign(hash, privatekey)
-	if err != nil {
-		return err
-	}
-	transaction.Sig = hexutil.Encode(sig)
-	transaction.Pub = hexutil.Encode(crypto.FromECDSAPub(&privatekey.PublicKey))
-	return nil
+	tmp.Write([]byte(p))
+	return tmp.Sum(nil)
 }
 
 //调用交易
 func (thk *Thk) CallTransaction(transaction *util.Transaction) (*dto.TxResult, error) {
+	return thk.CallTransactionContext(context.Background(), transaction)
+}
+
+func (thk *Thk) CallTransactionContext(ctx context.Context, transaction *util.Transaction) (*dto.TxResult, error) {
 	res := new(dto.TxResult)
-	if err := thk.provider.SendRequest(res, "CallTransaction", transaction); err != nil {
+	if err := thk.sendRequest(ctx, res, "CallTransaction", transaction); err != nil {
 		return nil, err
 	}
 	if res.ErrMsg != "" {
-		err := errors.New(res.ErrMsg)
+		err := &providers.NodeError{Method: "CallTransaction", Message: res.ErrMsg}
 		return nil, err
 	}
 	return res, nil
@@ -140,16 +194,20 @@ func (thk *Thk) CallTransaction(transaction *util.Transaction) (*dto.TxResult, e
 
 //通过hash获取交易11
 func (thk *Thk) GetTransactionByHash(chainId string, hash string) (*dto.TxResult, error) {
+	return thk.GetTransactionByHashContext(context.Background(), chainId, hash)
+}
+
+func (thk *Thk) GetTransactionByHashContext(ctx context.Context, chainId string, hash string) (*dto.TxResult, error) {
 	params := new(util.GetTxByHash)
 	if err := params.FormatParams(chainId, hash); err != nil {
 		return nil, err
 	}
 	res := new(dto.TxResult)
-	if err := thk.provider.SendRequest(res, "GetTransactionByHash", params); err != nil {
+	if err := thk.sendRequest(ctx, res, "GetTransactionByHash", params); err != nil {
 		return nil, err
 	}
 	if res.ErrMsg != "" {
-		err := errors.New(res.ErrMsg)
+		err := &providers.NodeError{Method: "GetTransactionByHash", Message: res.ErrMsg}
 		return nil, err
 	}
 	return res, nil
@@ -157,16 +215,20 @@ func (thk *Thk) GetTransactionByHash(chainId string, hash string) (*dto.TxResult
 
 //获取块结果11
 func (thk *Thk) GetBlockHeader(chainId string, height string) (*dto.GetBlockResult, error) {
+	return thk.GetBlockHeaderContext(context.Background(), chainId, height)
+}
+
+func (thk *Thk) GetBlockHeaderContext(ctx context.Context, chainId string, height string) (*dto.GetBlockResult, error) {
 	params := new(util.GetBlockHeader)
 	if err := params.FormatParams(chainId, height); err != nil {
 		return nil, err
 	}
 	res := new(dto.GetBlockResult)
-	if err := thk.provider.SendRequest(res, "GetBlockHeader", params); err != nil {
+	if err := thk.sendRequest(ctx, res, "GetBlockHeader", params); err != nil {
 		return nil, err
 	}
 	if res.ErrMsg != "" {
-		err := errors.New(res.ErrMsg)
+		err := &providers.NodeError{Method: "GetBlockHeader", Message: res.ErrMsg}
 		return nil, err
 	}
 	return res, nil
@@ -174,21 +236,27 @@ func (thk *Thk) GetBlockHeader(chainId string, height string) (*dto.GetBlockResu
 
 //11
 func (thk *Thk) Ping(chainId string) (int64, error) {
+	return thk.PingContext(context.Background(), chainId)
+}
+
+func (thk *Thk) PingContext(ctx context.Context, chainId string) (int64, error) {
 	params := new(util.PingJson)
 	if err := params.FormatParams(chainId); err != nil {
 		return 0, err
 	}
 	res := make(map[string]interface{})
-	if err := thk.provider.SendRequest(&res, "Ping", params); err != nil {
+	if err := thk.sendRequest(ctx, &res, "Ping", params); err != nil {
 		return 0, err
 	}
 
-	if _, ok := res["errMsg"]; ok {
-		return 0, errors.New(res["errMsg"].(string))
+	if err := mapNodeError("Ping", res); err != nil {
+		return 0, err
 	}
-	ret := int64(res["nonce"].(float64))
-
-	return ret, nil
+	nonce, ok := res["nonce"].(float64)
+	if !ok {
+		return 0, &providers.ProviderError{Method: "Ping", Err: fmt.Errorf("no nonce in response %v", res)}
+	}
+	return int64(nonce), nil
 }
 
 // func (thk *Thk) GetChainInfo(chainId string) {
@@ -210,16 +278,20 @@ func (thk *Thk) Ping(chainId string) (int64, error) {
 // }
 //19.5.25 获取链信息11
 func (thk *Thk) GetChainInfo(chainIds []int) ([]dto.GetChainInfo, error) {
+	return thk.GetChainInfoContext(context.Background(), chainIds)
+}
+
+func (thk *Thk) GetChainInfoContext(ctx context.Context, chainIds []int) ([]dto.GetChainInfo, error) {
 	params := new(util.GetChainInfoJson)
 	if err := params.FormatParams(chainIds); err != nil {
 		return nil, err
 	}
 	res := new(dto.GetChainInfo)
-	if err := thk.provider.SendRequest(res, "GetChainInfo", params); err != nil {
+	if err := thk.sendRequest(ctx, res, "GetChainInfo", params); err != nil {
 		return nil, err
 	}
 	if res.ErrMsg != "" {
-		err := errors.New(res.ErrMsg)
+		err := &providers.NodeError{Method: "GetChainInfo", Message: res.ErrMsg}
 		return nil, err
 	}
 
@@ -229,52 +301,107 @@ func (thk *Thk) GetChainInfo(chainIds []int) ([]dto.GetChainInfo, error) {
 
 //11
 func (thk *Thk) GetStats(chainId int) (gts dto.GetChainStats, err error) {
+	return thk.GetStatsContext(context.Background(), chainId)
+}
+
+func (thk *Thk) GetStatsContext(ctx context.Context, chainId int) (gts dto.GetChainStats, err error) {
 	params := new(util.GetStatsJson)
-	ers := params.FormatParams(chainId)
-	if ers != nil {
-		fmt.Println(ers)
+	if err := params.FormatParams(chainId); err != nil {
+		return gts, err
 	}
 
 	res := new(dto.GetChainStats)
-	if err := thk.provider.SendRequest(res, "GetStats", params); err != nil {
-		return *res, err
+	if err := thk.sendRequest(ctx, res, "GetStats", params); err != nil {
+		return gts, err
+	}
+	if res.ErrMsg != "" {
+		err := &providers.NodeError{Method: "GetStats", Message: res.ErrMsg}
+		return gts, err
+	}
+	if res.ChainId == 0 {
+		res.ChainId = chainId
 	}
-	res_array := dto.GetChainStats{ChainId: chainId}
+	return *res, nil
+}
 
-	return res_array, nil
+// GetMultiStats returns the stats of every chain in one request, ordered by
+// chain id. chainId names the chain whose node is asked.
+func (thk *Thk) GetMultiStats(chainId string) ([]dto.GetChainStats, error) {
+	return thk.GetMultiStatsContext(context.Background(), chainId)
+}
 
+func (thk *Thk) GetMultiStatsContext(ctx context.Context, chainId string) ([]dto.GetChainStats, error) {
+	params := new(util.GetMultiStatsJson)
+	if err := params.FormatParams(chainId); err != nil {
+		return nil, err
+	}
+	res := new(dto.GetMultiStatsResult)
+	if err := thk.sendRequest(ctx, res, "GetMultiStats", params); err != nil {
+		return nil, err
+	}
+	if res.ErrMsg != "" {
+		err := &providers.NodeError{Method: "GetMultiStats", Message: res.ErrMsg}
+		return nil, err
+	}
+	return res.Stats, nil
 }
 
 //GetTransactions
 func (thk *Thk) GetTransactions(chainId, address, startHeight, endHeight string) ([]dto.GetTransactions, error) {
+	return thk.GetTransactionsContext(context.Background(), chainId, address, startHeight, endHeight)
+}
+
+func (thk *Thk) GetTransactionsContext(ctx context.Context, chainId, address, startHeight, endHeight string) ([]dto.GetTransactions, error) {
 	params := new(util.GetTransactionsJson)
 	if err := params.FormatParams(chainId, address, startHeight, endHeight); err != nil {
 		return nil, err
 	}
 
-	res := new(dto.GetTransactions)
-	if err := thk.provider.SendRequest(res, "GetTransactions", params); err != nil {
+	var raw json.RawMessage
+	if err := thk.sendRequest(ctx, &raw, "GetTransactions", params); err != nil {
 		return nil, err
 	}
-
-	res_array := []dto.GetTransactions{*res}
-	return res_array, nil
+	// The node answers with a list, or with an object carrying ErrMsg.
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var res_array []dto.GetTransactions
+		if err := json.Unmarshal(raw, &res_array); err != nil {
+			return nil, &providers.ProviderError{Method: "GetTransactions", Body: raw, Err: err}
+		}
+		return res_array, nil
+	}
+	res := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, &providers.ProviderError{Method: "GetTransactions", Body: raw, Err: err}
+	}
+	if err := mapNodeError("GetTransactions", res); err != nil {
+		return nil, err
+	}
+	single := dto.GetTransactions{}
+	if err := json.Unmarshal(raw, &single); err != nil {
+		return nil, &providers.ProviderError{Method: "GetTransactions", Body: raw, Err: err}
+	}
+	return []dto.GetTransactions{single}, nil
 
 }
 
 //5.25 获取委员会详情11
 func (thk *Thk) GetCommittee(chainId string, epoch int) ([]string, error) {
+	return thk.GetCommitteeContext(context.Background(), chainId, epoch)
+}
+
+func (thk *Thk) GetCommitteeContext(ctx context.Context, chainId string, epoch int) ([]string, error) {
 	params := new(util.GetCommitteeJson)
 	if err := params.FormatParams(chainId, epoch); err != nil {
 		return nil, err
 	}
 
 	res := new(dto.GetCommittee)
-	if err := thk.provider.SendRequest(res, "GetCommittee", params); err != nil {
+	if err := thk.sendRequest(ctx, res, "GetCommittee", params); err != nil {
 		return nil, err
 	}
 	if res.ErrMsg != "" {
-		err := errors.New(res.ErrMsg)
+		err := &providers.NodeError{Method: "GetCommittee", Message: res.ErrMsg}
 		return nil, err
 	}
 	return res.MemberDetails, nil
@@ -282,12 +409,16 @@ func (thk *Thk) GetCommittee(chainId string, epoch int) ([]string, error) {
 
 //RpcMakeVccProof 11
 func (thk *Thk) RpcMakeVccProof(transaction *util.Transaction) (map[string]interface{}, error) {
+	return thk.RpcMakeVccProofContext(context.Background(), transaction)
+}
+
+func (thk *Thk) RpcMakeVccProofContext(ctx context.Context, transaction *util.Transaction) (map[string]interface{}, error) {
 	res := new(dto.RpcMakeVccProofJson)
-	if err := thk.provider.SendRequest(res, "RpcMakeVccProof", transaction); err != nil {
+	if err := thk.sendRequest(ctx, res, "RpcMakeVccProof", transaction); err != nil {
 		return nil, err
 	}
 	if res.ErrMsg != "" {
-		err := errors.New(res.ErrMsg)
+		err := &providers.NodeError{Method: "RpcMakeVccProof", Message: res.ErrMsg}
 		return nil, err
 	}
 	return res.Proof, nil
@@ -295,12 +426,16 @@ func (thk *Thk) RpcMakeVccProof(transaction *util.Transaction) (map[string]inter
 
 //MakeCCCExistenceProof  11
 func (thk *Thk) MakeCCCExistenceProof(transaction *util.Transaction) (map[string]interface{}, error) {
+	return thk.MakeCCCExistenceProofContext(context.Background(), transaction)
+}
+
+func (thk *Thk) MakeCCCExistenceProofContext(ctx context.Context, transaction *util.Transaction) (map[string]interface{}, error) {
 	res := new(dto.MakeCCCExistenceProofJson)
-	if err := thk.provider.SendRequest(res, "MakeCCCExistenceProof", transaction); err != nil {
+	if err := thk.sendRequest(ctx, res, "MakeCCCExistenceProof", transaction); err != nil {
 		return nil, err
 	}
 	if res.ErrMsg != "" {
-		err := errors.New(res.ErrMsg)
+		err := &providers.NodeError{Method: "MakeCCCExistenceProof", Message: res.ErrMsg}
 		return nil, err
 	}
 	return res.Proof, nil
@@ -308,12 +443,16 @@ func (thk *Thk) MakeCCCExistenceProof(transaction *util.Transaction) (map[string
 
 //GetCCCRelativeTx
 func (thk *Thk) GetCCCRelativeTx(transaction *util.Transaction) (map[string]interface{}, error) {
+	return thk.GetCCCRelativeTxContext(context.Background(), transaction)
+}
+
+func (thk *Thk) GetCCCRelativeTxContext(ctx context.Context, transaction *util.Transaction) (map[string]interface{}, error) {
 	res := new(dto.GetCCCRelativeTxJson)
-	if err := thk.provider.SendRequest(res, "GetCCCRelativeTx", transaction); err != nil {
+	if err := thk.sendRequest(ctx, res, "GetCCCRelativeTx", transaction); err != nil {
 		return nil, err
 	}
 	if res.ErrMsg != "" {
-		err := errors.New(res.ErrMsg)
+		err := &providers.NodeError{Method: "GetCCCRelativeTx", Message: res.ErrMsg}
 		return nil, err
 	}
 	return res.Proof, nil
@@ -321,17 +460,21 @@ func (thk *Thk) GetCCCRelativeTx(transaction *util.Transaction) (map[string]inte
 
 //CompileContract
 func (thk *Thk) CompileContract(chainId, contract string) (map[string]interface{}, error) {
+	return thk.CompileContractContext(context.Background(), chainId, contract)
+}
+
+func (thk *Thk) CompileContractContext(ctx context.Context, chainId, contract string) (map[string]interface{}, error) {
 	params := new(util.CompileContractJson)
 	ers := params.FormatParams(chainId, contract)
 	if ers != nil {
 		fmt.Println(ers)
 	}
 	res := new(dto.CompileContractJson)
-	if err := thk.provider.SendRequest(res, "CompileContract", params); err != nil {
+	if err := thk.sendRequest(ctx, res, "CompileContract", params); err != nil {
 		return nil, err
 	}
 	if res.ErrMsg != "" {
-		err := errors.New(res.ErrMsg)
+		err := &providers.NodeError{Method: "CompileContract", Message: res.ErrMsg}
 		return nil, err
 	}
 	return res.Test, nil
@@ -0,0 +1,298 @@
+package thk
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"web3.go/web3/dto"
+	"web3.go/web3/thk/util"
+)
+
+// TxState is the stage a tracked transaction has reached.
+type TxState int
+
+const (
+	TxSubmitted TxState = iota
+	// TxIncluded is reported when the transaction is found on chain, just
+	// before TxSucceeded or TxFailed.
+	TxIncluded
+	TxSucceeded
+	TxFailed
+	// TxExpired means the transaction was not included before the chain it
+	// targets passed its ExpireHeight; it can be refunded or issued again.
+	TxExpired
+)
+
+func (state TxState) String() string {
+	switch state {
+	case TxSubmitted:
+		return "submitted"
+	case TxIncluded:
+		return "included"
+	case TxSucceeded:
+		return "succeeded"
+	case TxFailed:
+		return "failed"
+	case TxExpired:
+		return "expired"
+	}
+	return "TxState(" + strconv.Itoa(int(state)) + ")"
+}
+
+// Final reports whether no further transition can follow state.
+func (state TxState) Final() bool {
+	return state == TxSucceeded || state == TxFailed || state == TxExpired
+}
+
+// TxEvent is one transition of a tracked transaction.
+type TxEvent struct {
+	Hash        string
+	Transaction util.Transaction
+	State       TxState
+	Receipt     *dto.TxResult // set from TxIncluded on
+	Height      int           // target chain height when TxExpired was decided
+}
+
+const DefaultTrackerPollInterval = 2 * time.Second
+
+// TxTracker follows submitted transactions until they succeed, fail or
+// expire. Every transition is passed to the callbacks registered with
+// OnEvent and sent on the Events channel, in order.
+type TxTracker struct {
+	thk      *Thk
+	interval time.Duration
+
+	mu        sync.Mutex
+	pending   map[string]*util.Transaction
+	states    map[string]TxState
+	callbacks []func(TxEvent)
+	events    chan TxEvent
+	// queue holds the transitions not delivered yet. They are queued in the
+	// order the states change and delivered by their own goroutine, so a
+	// callback or Events consumer may call Track or Submit.
+	queue []TxEvent
+	wake  chan struct{}
+
+	quit     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewTxTracker starts a tracker that checks its transactions every interval.
+// Zero means DefaultTrackerPollInterval. Call Stop to release it.
+func (thk *Thk) NewTxTracker(interval time.Duration) *TxTracker {
+	if interval <= 0 {
+		interval = DefaultTrackerPollInterval
+	}
+	tracker := &TxTracker{
+		thk:      thk,
+		interval: interval,
+		pending:  make(map[string]*util.Transaction),
+		states:   make(map[string]TxState),
+		wake:     make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+	}
+	tracker.wg.Add(2)
+	go tracker.loop()
+	go tracker.deliver()
+	return tracker
+}
+
+// OnEvent registers a callback for every transition. Callbacks are called one
+// at a time from the tracker's delivery goroutine, and may call Track or
+// Submit.
+func (tracker *TxTracker) OnEvent(callback func(TxEvent)) {
+	tracker.mu.Lock()
+	tracker.callbacks = append(tracker.callbacks, callback)
+	tracker.mu.Unlock()
+}
+
+// Events returns a channel receiving every transition from now on. Delivery
+// waits for the channel to be read, so a consumer must keep draining it until
+// Stop; transitions queue up meanwhile.
+func (tracker *TxTracker) Events() <-chan TxEvent {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if tracker.events == nil {
+		tracker.events = make(chan TxEvent, 16)
+	}
+	return tracker.events
+}
+
+// Submit sends a signed transaction and tracks it.
+func (tracker *TxTracker) Submit(ctx context.Context, transaction *util.Transaction) (string, error) {
+	hash, err := tracker.thk.SendTxContext(ctx, transaction)
+	if err != nil {
+		return "", err
+	}
+	tracker.Track(hash, transaction)
+	return hash, nil
+}
+
+// Track follows a transaction sent by other means.
+func (tracker *TxTracker) Track(hash string, transaction *util.Transaction) {
+	tx := *transaction
+	tracker.mu.Lock()
+	if _, ok := tracker.states[hash]; ok {
+		tracker.mu.Unlock()
+		return
+	}
+	tracker.pending[hash] = &tx
+	tracker.states[hash] = TxSubmitted
+	tracker.queue = append(tracker.queue, TxEvent{Hash: hash, Transaction: tx, State: TxSubmitted})
+	tracker.mu.Unlock()
+	tracker.notify()
+}
+
+// State returns the latest state of a tracked transaction.
+func (tracker *TxTracker) State(hash string) (TxState, bool) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	state, ok := tracker.states[hash]
+	return state, ok
+}
+
+// Stop ends tracking. Pending transactions stay in their current state, and
+// transitions not delivered yet are dropped.
+func (tracker *TxTracker) Stop() {
+	tracker.stopOnce.Do(func() { close(tracker.quit) })
+	tracker.wg.Wait()
+}
+
+func (tracker *TxTracker) loop() {
+	defer tracker.wg.Done()
+
+	ticker := time.NewTicker(tracker.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), tracker.interval)
+			tracker.check(ctx)
+			cancel()
+		case <-tracker.quit:
+			return
+		}
+	}
+}
+
+// check looks every pending transaction up once. A lookup that fails for any
+// reason but the node not knowing the transaction leaves it pending until the
+// next round.
+func (tracker *TxTracker) check(ctx context.Context) {
+	tracker.mu.Lock()
+	pending := make(map[string]util.Transaction, len(tracker.pending))
+	for hash, tx := range tracker.pending {
+		pending[hash] = *tx
+	}
+	tracker.mu.Unlock()
+
+	heights := make(map[int]int) // chain heights fetched this round
+	for hash, tx := range pending {
+		// The height is read before the lookup, so a transaction included
+		// in between is found rather than reported expired.
+		height, expires := tracker.height(ctx, tx, heights)
+		res, err := tracker.thk.GetTransactionByHashContext(ctx, tx.ChainId, hash)
+		if err == nil {
+			final := TxSucceeded
+			if res.Status != 1 {
+				final = TxFailed
+			}
+			tracker.transition(TxEvent{Hash: hash, Transaction: tx, State: TxIncluded, Receipt: res})
+			tracker.transition(TxEvent{Hash: hash, Transaction: tx, State: final, Receipt: res})
+			continue
+		}
+		if !isNotFound(err) || !expires {
+			continue
+		}
+		if height > tx.ExpireHeight {
+			tracker.transition(TxEvent{Hash: hash, Transaction: tx, State: TxExpired, Height: height})
+		}
+	}
+}
+
+// height returns the height of the chain tx expires on, fetching it once per
+// round into heights. It reports false when tx doesn't expire or the height
+// is unknown.
+func (tracker *TxTracker) height(ctx context.Context, tx util.Transaction, heights map[int]int) (int, bool) {
+	if tx.ExpireHeight <= 0 {
+		return 0, false
+	}
+	chain := tx.ToChainId
+	if chain == "" {
+		chain = tx.ChainId
+	}
+	chainId, err := strconv.Atoi(chain)
+	if err != nil {
+		return 0, false
+	}
+	if height, ok := heights[chainId]; ok {
+		return height, true
+	}
+	stats, err := tracker.thk.GetStatsContext(ctx, chainId)
+	if err != nil {
+		return 0, false
+	}
+	heights[chainId] = stats.Currentheight
+	return stats.Currentheight, true
+}
+
+func (tracker *TxTracker) transition(event TxEvent) {
+	tracker.mu.Lock()
+	tracker.states[event.Hash] = event.State
+	if event.State.Final() {
+		delete(tracker.pending, event.Hash)
+	}
+	tracker.queue = append(tracker.queue, event)
+	tracker.mu.Unlock()
+	tracker.notify()
+}
+
+// notify wakes the delivery goroutine up.
+func (tracker *TxTracker) notify() {
+	select {
+	case tracker.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver passes queued transitions to the callbacks and the Events channel
+// without holding any lock.
+func (tracker *TxTracker) deliver() {
+	defer tracker.wg.Done()
+
+	for {
+		select {
+		case <-tracker.wake:
+		case <-tracker.quit:
+			return
+		}
+		for {
+			tracker.mu.Lock()
+			if len(tracker.queue) == 0 {
+				tracker.mu.Unlock()
+				break
+			}
+			event := tracker.queue[0]
+			tracker.queue = tracker.queue[1:]
+			callbacks := tracker.callbacks
+			events := tracker.events
+			tracker.mu.Unlock()
+
+			for _, callback := range callbacks {
+				callback(event)
+			}
+			if events != nil {
+				select {
+				case events <- event:
+				case <-tracker.quit:
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package thk_test
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"web3.go/common/cryp/crypto"
+	"web3.go/web3/providers"
+	"web3.go/web3/thk"
+	"web3.go/web3/thk/backends"
+	"web3.go/web3/thk/util"
+)
+
+func TestTxTracker(t *testing.T) {
+	backend := backends.NewSimulatedBackend(2)
+	backend.Fund(2, testFrom, big.NewInt(100))
+	client := thk.NewThk(backend)
+	key, _ := crypto.HexToECDSA(testKey)
+
+	tracker := client.NewTxTracker(10 * time.Millisecond)
+	defer tracker.Stop()
+	events := tracker.Events()
+	var called int
+	tracker.OnEvent(func(thk.TxEvent) { called++ })
+
+	tx := &util.Transaction{ChainId: "2", From: testFrom, To: testTo, Value: "1", Nonce: "0"}
+	client.SignTransaction(tx, key)
+	sent, err := tracker.Submit(context.Background(), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Never sent, so it can only expire once chain 2 passes height 2.
+	tracker.Track("0xdead", &util.Transaction{ChainId: "2", ExpireHeight: 2})
+
+	backend.Commit()
+	want := map[string][]thk.TxState{
+		sent:     {thk.TxSubmitted, thk.TxIncluded, thk.TxSucceeded},
+		"0xdead": {thk.TxSubmitted, thk.TxExpired},
+	}
+	got := make(map[string][]thk.TxState)
+	timeout := time.After(5 * time.Second)
+	for n := 0; n < 5; n++ {
+		select {
+		case event := <-events:
+			got[event.Hash] = append(got[event.Hash], event.State)
+			if event.Hash == sent && event.State == thk.TxSucceeded && event.Receipt.BlockHeight != 1 {
+				t.Errorf("receipt %+v, want block 1", event.Receipt)
+			}
+			if event.State == thk.TxSubmitted {
+				// Move chain 2 to height 3 only once both are tracked.
+				if len(got) == 2 {
+					backend.Commit()
+					backend.Commit()
+				}
+			}
+		case <-timeout:
+			t.Fatalf("timed out with events %v", got)
+		}
+	}
+	for hash, states := range want {
+		if len(got[hash]) != len(states) {
+			t.Errorf("%s: got %v, want %v", hash, got[hash], states)
+			continue
+		}
+		for i := range states {
+			if got[hash][i] != states[i] {
+				t.Errorf("%s: got %v, want %v", hash, got[hash], states)
+				break
+			}
+		}
+	}
+	if state, _ := tracker.State("0xdead"); state != thk.TxExpired {
+		t.Errorf("State = %v, want expired", state)
+	}
+	if called != 5 {
+		t.Errorf("callback called %d times, want 5", called)
+	}
+}
+
+func TestTxTrackerTrackFromConsumers(t *testing.T) {
+	tracker := thk.NewThk(backends.NewSimulatedBackend(2)).NewTxTracker(time.Hour)
+	defer tracker.Stop()
+
+	// The callback tracks a follow-up for every transaction it hears of, and
+	// the Events consumer does the same, well past the channel's buffer.
+	const n = 40
+	tracker.OnEvent(func(event thk.TxEvent) {
+		if event.State == thk.TxSubmitted && event.Transaction.Nonce == "callback" {
+			tracker.Track(event.Hash+"-followup", &util.Transaction{ChainId: "2", Nonce: "followup"})
+		}
+	})
+	events := tracker.Events()
+	tracker.Track("0x00", &util.Transaction{ChainId: "2", Nonce: "callback"})
+
+	seen := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for len(seen) < 2*n {
+		select {
+		case event := <-events:
+			seen[event.Hash] = true
+			if i := len(seen); event.Transaction.Nonce == "callback" && i < 2*n {
+				tracker.Track("0x"+strconv.Itoa(i), &util.Transaction{ChainId: "2", Nonce: "callback"})
+			}
+		case <-timeout:
+			t.Fatalf("deadlocked after %d events", len(seen))
+		}
+	}
+}
+
+// unavailableLookups is a node past the expiry of every transaction whose
+// transaction lookups fail with a 503.
+type unavailableLookups struct {
+	*backends.SimulatedBackend
+}
+
+func (p unavailableLookups) SendRequest(v interface{}, method string, params interface{}) error {
+	if method == "GetTransactionByHash" {
+		return &providers.ProviderError{Method: method, StatusCode: 503}
+	}
+	return p.SimulatedBackend.SendRequest(v, method, params)
+}
+
+func TestTxTrackerLookupFailureIsNotExpiry(t *testing.T) {
+	backend := backends.NewSimulatedBackend(2)
+	for i := 0; i < 3; i++ {
+		backend.Commit()
+	}
+	tracker := thk.NewThk(unavailableLookups{backend}).NewTxTracker(5 * time.Millisecond)
+	tracker.Track("0xdead", &util.Transaction{ChainId: "2", ExpireHeight: 1})
+	time.Sleep(50 * time.Millisecond)
+	tracker.Stop()
+	tracker.Stop()
+
+	if state, _ := tracker.State("0xdead"); state != thk.TxSubmitted {
+		t.Errorf("State = %v after failed lookups, want submitted", state)
+	}
+}
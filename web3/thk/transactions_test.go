@@ -0,0 +1,61 @@
+package thk_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"web3.go/web3/providers"
+	"web3.go/web3/thk"
+)
+
+// rawProvider answers every request with the same JSON.
+type rawProvider string
+
+func (p rawProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	return json.Unmarshal([]byte(p), v)
+}
+
+func (p rawProvider) Close() error { return nil }
+
+func TestGetTransactions(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		answer string
+		hashes []string
+	}{
+		{"list", `[{"hash":"0x01","nonce":1},{"hash":"0x02","nonce":2}]`, []string{"0x01", "0x02"}},
+		{"empty list", ` []`, nil},
+		{"single object", `{"hash":"0x03","nonce":3}`, []string{"0x03"}},
+	} {
+		client := thk.NewThk(rawProvider(test.answer))
+		txs, err := client.GetTransactions("2", testFrom, "0", "10")
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if len(txs) != len(test.hashes) {
+			t.Errorf("%s: got %d transactions, want %d", test.name, len(txs), len(test.hashes))
+			continue
+		}
+		for i, tx := range txs {
+			if tx.Hash != test.hashes[i] {
+				t.Errorf("%s: transaction %d = %s, want %s", test.name, i, tx.Hash, test.hashes[i])
+			}
+		}
+	}
+
+	client := thk.NewThk(rawProvider(`{"ErrMsg":"invalid address"}`))
+	_, err := client.GetTransactions("2", testFrom, "0", "10")
+	var nodeErr *providers.NodeError
+	if !errors.As(err, &nodeErr) || nodeErr.Message != "invalid address" {
+		t.Errorf("got %v, want the node's error", err)
+	}
+
+	client = thk.NewThk(rawProvider(`[{"hash":1}]`))
+	_, err = client.GetTransactions("2", testFrom, "0", "10")
+	var providerErr *providers.ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Errorf("got %v, want a *ProviderError for the undecodable list", err)
+	}
+}
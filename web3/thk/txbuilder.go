@@ -0,0 +1,266 @@
+package thk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"web3.go/common/hexutil"
+	"web3.go/web3/thk/util"
+)
+
+// NilChainID is reserved by the node to mean "no chain" and can't be used in a
+// transaction.
+const NilChainID uint32 = math.MaxUint32
+
+// Tx is the typed form of util.Transaction. Use TxBuilder to construct one
+// and ParseTx to read a wire transaction back.
+type Tx struct {
+	ChainID     uint32 // chain the transaction is sent to, always FromChainID
+	FromChainID uint32
+	ToChainID   uint32
+	From        common.Address
+	To          *common.Address // nil deploys a contract
+	Nonce       uint64
+	Value       *big.Int
+	Input       []byte
+	// ExpireHeight is the height of ToChainID after which a cross-chain
+	// transfer can no longer be completed, 0 if unset.
+	ExpireHeight uint64
+}
+
+// Validate checks the fields the node would otherwise reject.
+func (tx *Tx) Validate() error {
+	for _, id := range []uint32{tx.ChainID, tx.FromChainID, tx.ToChainID} {
+		if id == NilChainID {
+			return fmt.Errorf("chain id %d is reserved", id)
+		}
+	}
+	if tx.FromChainID != tx.ChainID {
+		return fmt.Errorf("fromChainId %d differs from chainId %d", tx.FromChainID, tx.ChainID)
+	}
+	if tx.From == (common.Address{}) {
+		return errors.New("from address is not set")
+	}
+	if tx.To == nil && tx.ToChainID != tx.FromChainID {
+		return errors.New("a contract can only be deployed on the sending chain")
+	}
+	if tx.Value != nil && tx.Value.Sign() < 0 {
+		return fmt.Errorf("negative value %v", tx.Value)
+	}
+	if tx.ExpireHeight > uint64(^uint(0)>>1) {
+		return fmt.Errorf("expire height %d out of range", tx.ExpireHeight)
+	}
+	return nil
+}
+
+// Wire validates tx and returns it in the form SignTransaction and SendTx
+// take.
+func (tx *Tx) Wire() (*util.Transaction, error) {
+	if err := tx.Validate(); err != nil {
+		return nil, err
+	}
+	wire := &util.Transaction{
+		ChainId:      strconv.FormatUint(uint64(tx.ChainID), 10),
+		FromChainId:  strconv.FormatUint(uint64(tx.FromChainID), 10),
+		ToChainId:    strconv.FormatUint(uint64(tx.ToChainID), 10),
+		From:         strings.ToLower(tx.From.Hex()),
+		Nonce:        strconv.FormatUint(tx.Nonce, 10),
+		Value:        "0",
+		ExpireHeight: int(tx.ExpireHeight),
+	}
+	if tx.To != nil {
+		wire.To = strings.ToLower(tx.To.Hex())
+	}
+	if tx.Value != nil {
+		wire.Value = tx.Value.String()
+	}
+	if len(tx.Input) > 0 {
+		wire.Input = hexutil.Encode(tx.Input)
+	}
+	return wire, nil
+}
+
+// ParseTx converts a wire transaction to its typed form. Fields are parsed
+// strictly: decimal numbers without sign or prefix, 0x-prefixed addresses of
+// 20 bytes and 0x-prefixed input. Sig and Pub are not checked.
+func ParseTx(wire *util.Transaction) (*Tx, error) {
+	tx := new(Tx)
+	var err error
+	if tx.ChainID, err = parseChainID("chainId", wire.ChainId); err != nil {
+		return nil, err
+	}
+	tx.FromChainID, tx.ToChainID = tx.ChainID, tx.ChainID
+	if wire.FromChainId != "" {
+		if tx.FromChainID, err = parseChainID("fromChainId", wire.FromChainId); err != nil {
+			return nil, err
+		}
+	}
+	if wire.ToChainId != "" {
+		if tx.ToChainID, err = parseChainID("toChainId", wire.ToChainId); err != nil {
+			return nil, err
+		}
+	}
+	if tx.From, err = ParseAddress(wire.From); err != nil {
+		return nil, fmt.Errorf("from: %v", err)
+	}
+	if wire.To != "" {
+		to, err := ParseAddress(wire.To)
+		if err != nil {
+			return nil, fmt.Errorf("to: %v", err)
+		}
+		tx.To = &to
+	}
+	if !isDecimal(wire.Nonce) {
+		return nil, fmt.Errorf("nonce %q is not a decimal number", wire.Nonce)
+	}
+	if tx.Nonce, err = strconv.ParseUint(wire.Nonce, 10, 64); err != nil {
+		return nil, fmt.Errorf("nonce %q out of range", wire.Nonce)
+	}
+	if !isDecimal(wire.Value) {
+		return nil, fmt.Errorf("value %q is not a decimal number", wire.Value)
+	}
+	tx.Value, _ = new(big.Int).SetString(wire.Value, 10)
+	if wire.Input != "" {
+		if tx.Input, err = hexutil.Decode(wire.Input); err != nil {
+			return nil, fmt.Errorf("input: %v", err)
+		}
+	}
+	if wire.ExpireHeight < 0 {
+		return nil, fmt.Errorf("negative expire height %d", wire.ExpireHeight)
+	}
+	tx.ExpireHeight = uint64(wire.ExpireHeight)
+	if err := tx.Validate(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// ParseAddress parses a 0x-prefixed hex address of exactly 20 bytes.
+func ParseAddress(s string) (common.Address, error) {
+	if len(s) != 2+2*common.AddressLength {
+		return common.Address{}, fmt.Errorf("address %q is not 20 bytes of 0x-prefixed hex", s)
+	}
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("address %q: %v", s, err)
+	}
+	return common.BytesToAddress(b), nil
+}
+
+// TxBuilder assembles a Tx field by field. Errors are kept until Build so
+// calls can be chained:
+//
+//	wire, err := thk.NewTxBuilder(2).From(from).To(to).Nonce(nonce).Value(amount).Build()
+type TxBuilder struct {
+	tx  Tx
+	err error
+}
+
+// NewTxBuilder starts a transaction within chainId. Use ToChain for a
+// cross-chain transfer.
+func NewTxBuilder(chainId uint32) *TxBuilder {
+	return &TxBuilder{tx: Tx{ChainID: chainId, FromChainID: chainId, ToChainID: chainId}}
+}
+
+func (builder *TxBuilder) ToChain(chainId uint32) *TxBuilder {
+	builder.tx.ToChainID = chainId
+	return builder
+}
+
+func (builder *TxBuilder) From(from common.Address) *TxBuilder {
+	builder.tx.From = from
+	return builder
+}
+
+// FromHex is From with the address parsed by ParseAddress.
+func (builder *TxBuilder) FromHex(from string) *TxBuilder {
+	address, err := ParseAddress(from)
+	if err != nil && builder.err == nil {
+		builder.err = fmt.Errorf("from: %v", err)
+	}
+	return builder.From(address)
+}
+
+func (builder *TxBuilder) To(to common.Address) *TxBuilder {
+	builder.tx.To = &to
+	return builder
+}
+
+// ToHex is To with the address parsed by ParseAddress.
+func (builder *TxBuilder) ToHex(to string) *TxBuilder {
+	address, err := ParseAddress(to)
+	if err != nil && builder.err == nil {
+		builder.err = fmt.Errorf("to: %v", err)
+	}
+	return builder.To(address)
+}
+
+func (builder *TxBuilder) Nonce(nonce uint64) *TxBuilder {
+	builder.tx.Nonce = nonce
+	return builder
+}
+
+func (builder *TxBuilder) Value(value *big.Int) *TxBuilder {
+	builder.tx.Value = value
+	return builder
+}
+
+func (builder *TxBuilder) Input(input []byte) *TxBuilder {
+	builder.tx.Input = input
+	return builder
+}
+
+func (builder *TxBuilder) ExpireHeight(height uint64) *TxBuilder {
+	builder.tx.ExpireHeight = height
+	return builder
+}
+
+// Tx returns the typed transaction after validating it.
+func (builder *TxBuilder) Tx() (*Tx, error) {
+	if builder.err != nil {
+		return nil, builder.err
+	}
+	if err := builder.tx.Validate(); err != nil {
+		return nil, err
+	}
+	tx := builder.tx
+	return &tx, nil
+}
+
+// Build returns the wire transaction, ready for SignTransaction.
+func (builder *TxBuilder) Build() (*util.Transaction, error) {
+	tx, err := builder.Tx()
+	if err != nil {
+		return nil, err
+	}
+	return tx.Wire()
+}
+
+func parseChainID(field, s string) (uint32, error) {
+	if !isDecimal(s) {
+		return 0, fmt.Errorf("%s %q is not a decimal number", field, s)
+	}
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s %q out of range", field, s)
+	}
+	return uint32(id), nil
+}
+
+func isDecimal(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package thk
+
+import (
+	"math/big"
+	"testing"
+
+	"web3.go/web3/thk/util"
+)
+
+func TestTxBuilder(t *testing.T) {
+	value, _ := new(big.Int).SetString("10000000000000000000", 10)
+	wire, err := NewTxBuilder(2).ToChain(3).
+		FromHex("0x2C7536E3605D9C16A7A3D7B1898E529396A65C23").
+		ToHex("0x0000000000000000000000000000000000020000").
+		Nonce(43).Value(value).Input([]byte{0x01, 0xff}).ExpireHeight(284228).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := util.Transaction{
+		ChainId: "2", FromChainId: "2", ToChainId: "3",
+		From: "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", To: "0x0000000000000000000000000000000000020000",
+		Nonce: "43", Value: "10000000000000000000", Input: "0x01ff", ExpireHeight: 284228,
+	}
+	if *wire != want {
+		t.Errorf("Build() = %+v, want %+v", *wire, want)
+	}
+
+	tx, err := ParseTx(wire)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := tx.Wire()
+	if err != nil || *back != want {
+		t.Errorf("round trip = %+v, %v", back, err)
+	}
+
+	for name, builder := range map[string]*TxBuilder{
+		"short address":      NewTxBuilder(2).FromHex("0x2c7536e3605d9c16a7a3d7b1898e529396a65c").ToHex(want.To),
+		"missing from":       NewTxBuilder(2).ToHex(want.To),
+		"reserved chain":     NewTxBuilder(NilChainID).FromHex(want.From).ToHex(want.To),
+		"negative value":     NewTxBuilder(2).FromHex(want.From).ToHex(want.To).Value(big.NewInt(-1)),
+		"cross-chain deploy": NewTxBuilder(2).ToChain(3).FromHex(want.From),
+	} {
+		if _, err := builder.Build(); err == nil {
+			t.Errorf("%s: Build succeeded", name)
+		}
+	}
+
+	for _, bad := range []util.Transaction{
+		{ChainId: "2", From: want.From, To: want.To, Nonce: "0", Value: "0x10"},
+		{ChainId: "2", From: want.From, To: want.To, Nonce: "-1", Value: "1"},
+		{ChainId: "0x2", From: want.From, To: want.To, Nonce: "0", Value: "1"},
+		{ChainId: "2", FromChainId: "3", From: want.From, To: want.To, Nonce: "0", Value: "1"},
+		{ChainId: "2", From: want.From, To: want.To, Nonce: "0", Value: "1", Input: "01ff"},
+	} {
+		if _, err := ParseTx(&bad); err == nil {
+			t.Errorf("ParseTx(%+v) succeeded", bad)
+		}
+	}
+}
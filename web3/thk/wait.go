@@ -0,0 +1,133 @@
+package thk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"web3.go/web3/dto"
+	"web3.go/web3/providers"
+)
+
+const (
+	waitPollInitial = 100 * time.Millisecond
+	waitPollMax     = 2 * time.Second
+)
+
+// TxFailedError is returned when a transaction was included with a status
+// other than 1. It unwraps to the decoded *ContractRevertError.
+type TxFailedError struct {
+	Hash    string
+	Status  int
+	Receipt *dto.TxResult
+	Revert  *ContractRevertError
+}
+
+func (e *TxFailedError) Error() string {
+	if e.Revert != nil && len(e.Revert.Data) > 0 {
+		return fmt.Sprintf("transaction %s failed with status %d: %v", e.Hash, e.Status, e.Revert)
+	}
+	return fmt.Sprintf("transaction %s failed with status %d", e.Hash, e.Status)
+}
+
+func (e *TxFailedError) Unwrap() error {
+	if e.Revert == nil {
+		return nil
+	}
+	return e.Revert
+}
+
+// WaitForTx polls GetTransactionByHash, backing off up to 2s between
+// attempts, until the transaction is included or ctx is done. A transaction
+// that was included but failed is returned along with a *TxFailedError.
+//
+// Polling goes on while the node reports the transaction as not found or
+// can't be reached; any other error, such as a bad chainId or a response that
+// does not decode, is returned at once.
+func (thk *Thk) WaitForTx(ctx context.Context, chainId string, hash string) (*dto.TxResult, error) {
+	var (
+		res     *dto.TxResult
+		lastErr error
+	)
+	err := poll(ctx, func() (bool, error) {
+		res, lastErr = thk.GetTransactionByHashContext(ctx, chainId, hash)
+		if lastErr != nil && !isNotFound(lastErr) && !providers.IsTransportError(lastErr) {
+			return false, lastErr
+		}
+		return lastErr == nil, nil
+	})
+	if err != nil {
+		if err == lastErr {
+			return nil, fmt.Errorf("waiting for transaction %s: %w", hash, err)
+		}
+		if lastErr != nil {
+			return nil, fmt.Errorf("waiting for transaction %s: %w (last error: %v)", hash, err, lastErr)
+		}
+		return nil, err
+	}
+	if res.Status != 1 {
+		return res, &TxFailedError{Hash: hash, Status: res.Status, Receipt: res, Revert: newRevertError(nil, res)}
+	}
+	return res, nil
+}
+
+// WaitForFinality waits for the transaction like WaitForTx and then for
+// confirmations more blocks on chainId, watching the height GetStats
+// reports.
+func (thk *Thk) WaitForFinality(ctx context.Context, chainId string, hash string, confirmations int) (*dto.TxResult, error) {
+	id, err := strconv.Atoi(chainId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chainId %q", chainId)
+	}
+	res, err := thk.WaitForTx(ctx, chainId, hash)
+	if err != nil {
+		return res, err
+	}
+	target := res.BlockHeight + confirmations
+	err = poll(ctx, func() (bool, error) {
+		stats, err := thk.GetStatsContext(ctx, id)
+		if err != nil && !providers.IsTransportError(err) {
+			return false, err
+		}
+		return err == nil && stats.Currentheight >= target, nil
+	})
+	return res, err
+}
+
+// isNotFound reports whether the node rejected a lookup because it does not
+// know the transaction yet.
+func isNotFound(err error) bool {
+	var nodeErr *providers.NodeError
+	if !errors.As(err, &nodeErr) {
+		return false
+	}
+	message := strings.ToLower(nodeErr.Message)
+	if !strings.Contains(message, "not found") && !strings.Contains(message, "not exist") {
+		return false
+	}
+	return strings.Contains(message, "transaction") || strings.Contains(message, "tx") || strings.Contains(message, "hash")
+}
+
+// poll calls check with exponential backoff until it reports done, fails or
+// ctx is done.
+func poll(ctx context.Context, check func() (bool, error)) error {
+	interval := waitPollInitial
+	for {
+		if done, err := check(); done || err != nil {
+			return err
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		if interval *= 2; interval > waitPollMax {
+			interval = waitPollMax
+		}
+	}
+}
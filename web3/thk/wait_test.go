@@ -0,0 +1,104 @@
+package thk_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"web3.go/common/cryp/crypto"
+	"web3.go/web3/providers"
+	"web3.go/web3/thk"
+	"web3.go/web3/thk/backends"
+	"web3.go/web3/thk/util"
+)
+
+func TestWaitForFinality(t *testing.T) {
+	backend := backends.NewSimulatedBackend(2)
+	backend.Fund(2, testFrom, big.NewInt(100))
+	client := thk.NewThk(backend)
+	key, _ := crypto.HexToECDSA(testKey)
+
+	tx := &util.Transaction{ChainId: "2", From: testFrom, To: testTo, Value: "1", Nonce: "0"}
+	client.SignTransaction(tx, key)
+	hash, err := client.SendTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mint a block every 20ms in the background.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				backend.Commit()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := client.WaitForFinality(ctx, "2", hash, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, _ := client.GetStats(2)
+	if res.TransactionHash != hash || stats.Currentheight < res.BlockHeight+3 {
+		t.Errorf("finality reported for %s at height %d with the chain at %d", res.TransactionHash, res.BlockHeight, stats.Currentheight)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	if _, err := client.WaitForTx(ctx, "2", "0x01"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+// receiptProvider answers every request with the same receipt.
+type receiptProvider struct {
+	receipt map[string]interface{}
+}
+
+func (p receiptProvider) SendRequest(v interface{}, method string, params interface{}) error {
+	body, _ := json.Marshal(p.receipt)
+	return json.Unmarshal(body, v)
+}
+
+func (p receiptProvider) Close() error { return nil }
+
+func TestWaitForTxFailed(t *testing.T) {
+	client := thk.NewThk(receiptProvider{map[string]interface{}{"transactionHash": "0x01", "status": 0, "blockHeight": 5}})
+	res, err := client.WaitForTx(context.Background(), "2", "0x01")
+	var failed *thk.TxFailedError
+	if !errors.As(err, &failed) || failed.Status != 0 {
+		t.Fatalf("got %v, want a *TxFailedError", err)
+	}
+	if res == nil || res.BlockHeight != 5 {
+		t.Errorf("receipt = %+v, want the failed receipt", res)
+	}
+}
+
+func TestWaitForTxPermanentError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for name, client := range map[string]*thk.Thk{
+		"unknown chain": thk.NewThk(backends.NewSimulatedBackend(2)),
+		"rejected":      thk.NewThk(receiptProvider{map[string]interface{}{"ErrMsg": "invalid params"}}),
+	} {
+		start := time.Now()
+		_, err := client.WaitForTx(ctx, "9", "0x01")
+		var nodeErr *providers.NodeError
+		if !errors.As(err, &nodeErr) {
+			t.Errorf("%s: got %v, want the node's error", name, err)
+		}
+		if time.Since(start) > time.Second {
+			t.Errorf("%s: kept polling for %v", name, time.Since(start))
+		}
+	}
+}